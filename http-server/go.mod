@@ -15,6 +15,7 @@ require (
 	github.com/supabase-community/gotrue-go v1.2.0
 	github.com/zishang520/engine.io/v2 v2.3.1
 	github.com/zishang520/socket.io/v2 v2.3.7
+	golang.org/x/crypto v0.36.0
 	gorm.io/driver/postgres v1.5.11
 	gorm.io/gorm v1.25.12
 )
@@ -91,7 +92,6 @@ require (
 	github.com/zishang520/socket.io-go-parser/v2 v2.3.0 // indirect
 	go.uber.org/mock v0.5.0 // indirect
 	golang.org/x/arch v0.13.0 // indirect
-	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
 	golang.org/x/mod v0.18.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
@@ -10,8 +10,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	e "pry-teams/src/event"
+	"pry-teams/src/lib"
+	authn "pry-teams/src/lib/auth"
+	"pry-teams/src/lib/broadcast"
 	c "pry-teams/src/lib/common"
 	"pry-teams/src/lib/database"
+	"pry-teams/src/lib/schedule"
 	"pry-teams/src/middleware"
 	r "pry-teams/src/repository"
 	"pry-teams/src/routes"
@@ -28,6 +33,8 @@ import (
 type Server struct {
 	httpServer *http.Server
 	peerServer *peer.PeerServer
+	emitter    *broadcast.Emitter
+	events     *lib.SocketContext
 }
 
 var (
@@ -36,6 +43,9 @@ var (
 	host     = c.Env("HOST")
 	port     = c.Env("PORT")
 	address  = fmt.Sprintf("%s:%s", host, port)
+	instance = c.InstanceID()
+	// instances without a heartbeat for three intervals are considered stopped
+	heartbeat = c.EnvDuration("INSTANCE_HEARTBEAT", 15*time.Second)
 )
 
 func main() {
@@ -60,7 +70,8 @@ func main() {
 	}()
 
 	go func() {
-		database.CleanUp()
+		server.CleanUp()
+		server.Heartbeat(ctx)
 		if *showVersion {
 			slog.Info("System",
 				slog.String("version", info.Version),
@@ -74,7 +85,7 @@ func main() {
 
 	<-ctx.Done()
 
-	database.CleanUp()
+	server.CleanUp()
 	if err := database.Disconnect(); err != nil {
 		slog.Error("Close database connection", slog.Any("error", err))
 		panic(err)
@@ -92,7 +103,22 @@ func NewServer() *Server {
 	s := socket.NewServer(nil, nil)
 	sc := socket.DefaultServerOptions()
 
-	CreateEvent(s, services)
+	adapter, err := broadcast.New(c.Env("SOCKET_ADAPTER"), db, c.Env("DATABASE_URL"))
+	if err != nil {
+		slog.Error("Socket adapter", slog.Any("error", err))
+		panic(err)
+	}
+	emitter := broadcast.NewEmitter(s, adapter, instance)
+
+	slog.Info("Auth provider", slog.String("name", authn.Default().Name()))
+
+	events := &lib.SocketContext{
+		Io:             s,
+		Timers:         schedule.New(),
+		Emitter:        emitter,
+		ServiceContext: services,
+	}
+	CreateEvent(events)
 
 	r := gin.Default()
 	r.Static("/public", "public")
@@ -101,8 +127,9 @@ func NewServer() *Server {
 	r.Use(middleware.Cors())
 	r.GET("/socket.io/*any", gin.WrapH(s.ServeHandler(sc)))
 	r.POST("/socket.io/*any", gin.WrapH(s.ServeHandler(sc)))
-	r.Use(middleware.SupbaseAuth())
-	routes.Api(r.Group("/api"), services)
+	routes.Public(r.Group("/api"), services)
+	r.Use(middleware.Auth())
+	routes.Api(r.Group("/api"), services, emitter)
 
 	op := peer.NewOptions()
 	op.Host = host
@@ -110,6 +137,8 @@ func NewServer() *Server {
 	op.Path = "/"
 
 	return &Server{
+		emitter:    emitter,
+		events:     events,
 		peerServer: peer.New(op),
 		httpServer: &http.Server{
 			Addr:    address,
@@ -119,7 +148,15 @@ func NewServer() *Server {
 }
 
 func (s *Server) Start() {
-	slog.Info("Server started", slog.String("host", host))
+	slog.Info("Server started",
+		slog.String("host", host),
+		slog.String("instance", instance),
+	)
+
+	if err := s.emitter.Start(); err != nil {
+		slog.Error("Start socket adapter", slog.Any("error", err))
+		panic("Start socket adapter")
+	}
 
 	if c.Env("EXPERIMENTAL_HTTPS") == "true" {
 		if err := s.peerServer.StartTLS(certFile, certKey); err != nil {
@@ -146,6 +183,49 @@ func (s *Server) Start() {
 	}
 }
 
+// Heartbeat keeps the instance alive in the database and removes the people
+// left behind by stopped instances, the first beat is sent before returning
+// so the people joining this instance are never taken for stale
+func (s *Server) Heartbeat(ctx context.Context) {
+	s.beat()
+
+	go func() {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.beat()
+			}
+		}
+	}()
+}
+
+func (s *Server) beat() {
+	people, err := database.Heartbeat(instance, 3*heartbeat)
+	if err != nil {
+		slog.Error("Heartbeat", slog.Any("error", err))
+		return
+	}
+
+	if len(people) > 0 {
+		slog.Info("Heartbeat", slog.Int("removed", len(people)))
+		e.DropPeople(s.events, people)
+	}
+}
+
+// CleanUp removes the people of this instance and lets their rooms know,
+// the broadcast reaches the people connected to the other instances
+func (s *Server) CleanUp() {
+	people := database.CleanUp(instance)
+	if len(people) > 0 {
+		e.DropPeople(s.events, people)
+	}
+}
+
 func (s *Server) Stop(timeout time.Duration) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -160,5 +240,9 @@ func (s *Server) Stop(timeout time.Duration) {
 		panic("Stop Http server")
 	}
 
+	if err := s.emitter.Close(); err != nil {
+		slog.Error("Stop socket adapter", slog.Any("error", err))
+	}
+
 	slog.Info("Server stopped")
 }
@@ -5,42 +5,47 @@ import (
 	"log/slog"
 	e "pry-teams/src/event"
 	"pry-teams/src/lib"
-	"pry-teams/src/lib/database"
-	"pry-teams/src/services"
+	authn "pry-teams/src/lib/auth"
 
 	"github.com/zishang520/engine.io/v2/events"
 	s "github.com/zishang520/socket.io/v2/socket"
 )
 
-func CreateEvent(io *s.Server, services *services.ServiceContext) {
+// CreateEvent registers the socket events, every connection gets a copy
+// of the server context with its own socket
+func CreateEvent(server *lib.SocketContext) {
+	io := server.Io
 	io.Use(auth)
 
 	io.On("connection", func(a ...any) {
 		socket := a[0].(*s.Socket)
 		slog.Info(fmt.Sprintf("connected: %s", socket.Id()))
 
-		ctx := lib.SocketContext{
-			Io:             io,
-			Socket:         socket,
-			ServiceContext: services,
-		}
+		ctx := *server
+		ctx.Socket = socket
 
 		room := e.NewRoomEvent(&ctx)
 		host := e.NewHostEvent(&ctx)
 		user := e.NewUserEvent(&ctx)
 		chat := e.NewChatEvent(&ctx)
+		session := e.NewSessionEvent(&ctx)
+
+		session.Watch()
+		socket.On("auth:refresh", session.OnRefresh)
 
 		socket.On("request:join", room.AskToJoin)
-		socket.On("request:accept", room.OnAccept)
-		socket.On("request:reject", room.OnReject)
+		socket.On("request:accept", e.HostOnly(&ctx, "request:accept", room.OnAccept))
+		socket.On("request:reject", e.HostOnly(&ctx, "request:reject", room.OnReject))
 
 		socket.On("room:count", room.OnCount)
 		socket.On("room:join", room.OnJoined)
 
-		socket.On("host:mute-user", host.OnMuteUser)
-		socket.On("host:remove-user", host.OnRemoveUser)
-		socket.On("host:change-control", host.OnChangeControl)
-		socket.On("host:remove-shared-screen", host.OnRemoveScreen)
+		socket.On("host:mute-user", e.HostOnly(&ctx, "host:mute-user", host.OnMuteUser))
+		socket.On("host:remove-user", e.HostOnly(&ctx, "host:remove-user", host.OnRemoveUser))
+		socket.On("host:change-control", e.HostOnly(&ctx, "host:change-control", host.OnChangeControl))
+		socket.On("host:remove-shared-screen", e.HostOnly(&ctx, "host:remove-shared-screen", host.OnRemoveScreen))
+		socket.On("host:set-role", e.HostOnly(&ctx, "host:set-role", host.OnSetRole))
+		socket.On("host:lock-room", e.HostOnly(&ctx, "host:lock-room", host.OnLockRoom))
 
 		socket.On("user:leave", user.OnLeave)
 		socket.On("user:reaction", user.OnReaction)
@@ -50,8 +55,15 @@ func CreateEvent(io *s.Server, services *services.ServiceContext) {
 		socket.On("user:stop-share-screen", user.StopShareScreen)
 		socket.On("user:disable-microphone", user.OnDisableMicrophone)
 		socket.On("user:disable-camera", user.OnDisableCamera)
+		socket.On("user:devices", user.OnDevices)
+		socket.On("user:transfer", user.OnTransfer)
 
 		socket.On("chat:post", chat.OnPost)
+		socket.On("chat:direct", chat.OnDirect)
+		socket.On("chat:edit", chat.OnEdit)
+		socket.On("chat:delete", chat.OnDelete)
+		socket.On("chat:react", chat.OnReact)
+		socket.On("chat:thread", chat.OnThread)
 
 		socket.On("disconnect", disconnect(&ctx))
 	})
@@ -61,17 +73,8 @@ func disconnect(ctx *lib.SocketContext) events.Listener {
 	return func(a ...any) {
 		id := string(ctx.Socket.Id())
 		slog.Info(fmt.Sprintf("disconnect: %s", id))
-
-		user, count, err := ctx.People.Disconnect(id)
-		if err != nil {
-			slog.Error("Disconnect:", slog.Any("error", err))
-			return
-		}
-
-		ctx.Socket.To(s.Room(user.RoomID)).Emit("room:leave", user.PeerID)
-		ctx.Io.To(s.Room(user.RoomID)).Emit("room:count", count)
-
-		ctx.Socket.Leave(s.Room(user.RoomID))
+		e.StopSession(ctx)
+		e.KeepSeat(ctx)
 	}
 }
 
@@ -88,10 +91,7 @@ func auth(socket *s.Socket, next func(*s.ExtendedError)) {
 		return
 	}
 
-	supabase := database.Supabase()
-	supabaseAuth := supabase.Auth.WithToken(token)
-
-	user, err := supabaseAuth.GetUser()
+	user, err := authn.AuthenticateSocket(token)
 	if err != nil {
 		next(s.NewExtendedError("unauthorized", nil))
 		return
@@ -99,8 +99,9 @@ func auth(socket *s.Socket, next func(*s.ExtendedError)) {
 
 	slog.Info(
 		"User",
-		slog.Any("id", user.ID),
-		slog.Any("name", user.UserMetadata["name"]),
+		slog.String("id", user.ID),
+		slog.String("name", user.Name),
+		slog.Bool("guest", user.Guest),
 	)
 
 	socket.SetData(map[string]any{"user": user})
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"pry-teams/src/services"
+	"pry-teams/src/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	service *services.CalendarService
+}
+
+func NewCalendarController(service *services.CalendarService) *CalendarController {
+	return &CalendarController{service: service}
+}
+
+func (c *CalendarController) Invite(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id := ctx.Param("id")
+
+	calendar, err := c.service.Invite(id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(404, gin.H{"error": "Invalid code or link"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, id))
+	ctx.Data(200, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+// Feed is public, calendar clients subscribe to the url without authorization
+func (c *CalendarController) Feed(ctx *gin.Context) {
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	calendar, err := c.service.Feed(token)
+	if err != nil {
+		ctx.AbortWithStatusJSON(404, gin.H{"error": "Not found"})
+		return
+	}
+
+	ctx.Data(200, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+func (c *CalendarController) GetToken(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := c.service.GetToken(user.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"token": token,
+		"path":  fmt.Sprintf("/api/calendar/%s.ics", token),
+	})
+}
+
+func (c *CalendarController) RefreshToken(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := c.service.RefreshToken(user.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"token": token,
+		"path":  fmt.Sprintf("/api/calendar/%s.ics", token),
+	})
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"pry-teams/src/services"
+	"pry-teams/src/types"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChatController struct {
+	service *services.ChatService
+}
+
+func NewChatController(service *services.ChatService) *ChatController {
+	return &ChatController{service: service}
+}
+
+func (c *ChatController) GetMessages(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(404, gin.H{"error": "Not found"})
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	messages, total, err := c.service.GetMessages(id, user.ID, page, limit)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"messages": messages,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
+
+// SearchMessages searches the chat history of the room for the hosts and
+// the participants of the room
+func (c *ChatController) SearchMessages(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" || len(query) > 200 {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid query"})
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	results, total, err := c.service.Search(ctx.Param("id"), user.ID, query, page, limit)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"results": results,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
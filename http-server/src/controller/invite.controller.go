@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"pry-teams/src/services"
+	"pry-teams/src/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InviteController struct {
+	service *services.InviteService
+}
+
+func NewInviteController(service *services.InviteService) *InviteController {
+	return &InviteController{service: service}
+}
+
+func (c *InviteController) CreateInvite(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var data types.InviteRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, err := c.service.Create(ctx.Param("id"), user.ID, &data)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error":  nil,
+		"invite": invite,
+	})
+}
+
+func (c *InviteController) GetInvites(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	invites, err := c.service.GetInvites(ctx.Param("id"), user.ID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error":   nil,
+		"invites": invites,
+	})
+}
+
+func (c *InviteController) RevokeInvite(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err := c.service.Revoke(ctx.Param("id"), user.ID, ctx.Param("inviteId"))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{"error": nil})
+}
@@ -1,19 +1,24 @@
 package controller
 
 import (
-	"pry-teams/src/lib/array"
+	"errors"
+	"pry-teams/src/lib/broadcast"
 	"pry-teams/src/services"
 	"pry-teams/src/types"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	s "github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
 )
 
 type RoomController struct {
 	service *services.RoomService
+	emitter *broadcast.Emitter
 }
 
-func NewRoomController(service *services.RoomService) *RoomController {
-	return &RoomController{service: service}
+func NewRoomController(service *services.RoomService, emitter *broadcast.Emitter) *RoomController {
+	return &RoomController{service: service, emitter: emitter}
 }
 
 func (c *RoomController) GetRoom(ctx *gin.Context) {
@@ -35,9 +40,16 @@ func (c *RoomController) GetRoom(ctx *gin.Context) {
 		return
 	}
 
+	role, err := c.service.GetRole(room.RoomId, user.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx.AbortWithStatusJSON(200, gin.H{
 		"room": room,
-		"host": array.Include(room.Host, user.ID.String()),
+		"host": role.IsHost(),
+		"role": role,
 	})
 }
 
@@ -48,7 +60,7 @@ func (c *RoomController) CreateRoom(ctx *gin.Context) {
 		return
 	}
 
-	room, err := c.service.CreateRoom(user.ID.String())
+	room, err := c.service.CreateRoom(user.AuthUser, nil)
 	if err != nil {
 		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
 		return
@@ -59,3 +71,292 @@ func (c *RoomController) CreateRoom(ctx *gin.Context) {
 		"room":  room.RoomId,
 	})
 }
+
+func (c *RoomController) ScheduleRoom(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var schedule types.RoomSchedule
+	if err := ctx.ShouldBindJSON(&schedule); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	room, err := c.service.CreateRoom(user.AuthUser, &schedule)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(201, gin.H{
+		"error": nil,
+		"room":  room,
+	})
+}
+
+func (c *RoomController) UpdateRoom(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var schedule types.RoomSchedule
+	if err := ctx.ShouldBindJSON(&schedule); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	room, err := c.service.UpdateRoom(ctx.Param("id"), user.ID, &schedule)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error": nil,
+		"room":  room,
+	})
+}
+
+func (c *RoomController) DeleteRoom(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// the people in the room are evicted and told before the room is gone
+	roomId := ctx.Param("id")
+	if err := c.service.EndRoom(roomId, user.ID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	room := s.Room(roomId)
+	c.emitter.To(room).Emit("room:ended", roomId)
+	c.emitter.In(room).SocketsLeave(room)
+
+	if err := c.service.DeleteRoom(roomId, user.ID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{"error": nil})
+}
+
+// GetRooms lists the upcoming meetings hosted by the user,
+// defaults to the next 30 days
+func (c *RoomController) GetRooms(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	from := time.Now()
+	if value := ctx.Query("from"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid from date"})
+			return
+		}
+		from = t
+	}
+
+	to := from.AddDate(0, 0, 30)
+	if value := ctx.Query("to"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil || !t.After(from) {
+			ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid to date"})
+			return
+		}
+		to = t
+	}
+
+	if to.Sub(from) > 366*24*time.Hour {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": "Date range is too large"})
+		return
+	}
+
+	meetings, err := c.service.GetMeetings(user.ID, from, to)
+	if err != nil {
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{"meetings": meetings})
+}
+
+// GetHostedRooms lists the rooms hosted by the user, archived rooms
+// are listed with the archived query
+func (c *RoomController) GetHostedRooms(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rooms, err := c.service.GetHostedRooms(user.ID, ctx.Query("archived") == "true")
+	if err != nil {
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{"rooms": rooms})
+}
+
+func (c *RoomController) UpdateDetails(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var details types.RoomDetails
+	if err := ctx.ShouldBindJSON(&details); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	room, err := c.service.UpdateDetails(ctx.Param("id"), user.ID, &details)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error": nil,
+		"room":  room,
+	})
+}
+
+func (c *RoomController) AddHost(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var host types.RoomHost
+	if err := ctx.ShouldBindJSON(&host); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := c.service.AddHost(ctx.Param("id"), user.ID, host.UserID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error": nil,
+		"roles": roles,
+	})
+}
+
+func (c *RoomController) CreateGuest(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var guest types.GuestRequest
+	if err := ctx.ShouldBindJSON(&guest); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := c.service.CreateGuestToken(ctx.Param("id"), user.ID, guest.Name)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error": nil,
+		"guest": token,
+	})
+}
+
+func (c *RoomController) RemoveHost(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	roles, err := c.service.RemoveHost(ctx.Param("id"), user.ID, ctx.Param("userId"))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error": nil,
+		"roles": roles,
+	})
+}
+
+func (c *RoomController) GetControl(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	control, err := c.service.GetControl(ctx.Param("id"), user.ID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.AbortWithStatusJSON(200, gin.H{"control": control})
+}
+
+func (c *RoomController) UpdateControl(ctx *gin.Context) {
+	var user types.UserResponse
+	if err := user.Get(ctx); err != nil {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var state types.Control
+	if err := ctx.ShouldBindJSON(&state); err != nil {
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	control, err := c.service.SetControl(ctx.Param("id"), user.ID, &state)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	// the passcode and the allowlist are never sent to the room
+	c.emitter.To(s.Room(control.RoomID)).Emit("user:control-changed", control.Control())
+
+	ctx.AbortWithStatusJSON(200, gin.H{
+		"error":   nil,
+		"control": control,
+	})
+}
+
+func abortWithError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.AbortWithStatusJSON(404, gin.H{"error": "Invalid code or link"})
+	case errors.Is(err, types.ErrForbidden), errors.Is(err, types.ErrNotParticipant):
+		ctx.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+	case errors.Is(err, types.ErrSchedule), errors.Is(err, types.ErrRole), errors.Is(err, types.ErrArchived),
+		errors.Is(err, types.ErrPasscodeFormat), errors.Is(err, types.ErrInviteExpiry):
+		ctx.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		ctx.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+	}
+}
@@ -23,5 +23,191 @@ func (e *ChatEvent) OnPost(a ...any) {
 		slog.Error("Chat post: Invalid argument")
 		return
 	}
-	e.ctx.Socket.To(s.Room(args.RoomID)).Emit("chat:get", args.Message)
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat post:", slog.Any("error", err))
+		return
+	}
+
+	if err := checkPermission(e.ctx, args.RoomID, t.AllowSendChat); err != nil {
+		slog.Warn("Chat post:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-post", err.Error())
+		return
+	}
+
+	// the message is rewritten with the server side sender, id and timestamp
+	message, err := e.ctx.Chat.Post(args.RoomID, user.ID, string(e.ctx.Socket.Id()), &args.Message)
+	if err != nil {
+		slog.Error("Chat post:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-post", err.Error())
+		return
+	}
+
+	// the sender learns the id and the timestamp given by the server
+	e.ctx.Socket.Emit("chat:posted", args.Message)
+
+	if message.ParentID == nil {
+		e.ctx.Broadcast(s.Room(args.RoomID)).Emit("chat:get", args.Message)
+		return
+	}
+
+	// replies stay out of the main stream, the room only learns the new count
+	e.ctx.Broadcast(s.Room(args.RoomID)).Emit("chat:reply", args.Message)
+
+	delta, err := e.ctx.Chat.ThreadUpdate(args.RoomID, *message.ParentID)
+	if err != nil {
+		slog.Error("Chat post:", slog.Any("error", err))
+		return
+	}
+
+	e.ctx.Emitter.To(s.Room(args.RoomID)).Emit("chat:thread-updated", delta)
+}
+
+// OnThread sends a page of the replies of a thread to the socket
+func (e *ChatEvent) OnThread(a ...any) {
+	args, err := c.BindMap[t.ChatThread](a[0])
+	if err != nil {
+		slog.Error("Chat thread: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat thread:", slog.Any("error", err))
+		return
+	}
+
+	thread, err := e.ctx.Chat.Thread(
+		args.RoomID, user.ID, string(e.ctx.Socket.Id()), args.MessageID, args.Page,
+	)
+	if err != nil {
+		slog.Error("Chat thread:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-thread", err.Error())
+		return
+	}
+
+	e.ctx.Socket.Emit("chat:thread", thread)
+}
+
+// OnDirect sends a private message to a single participant of the room
+func (e *ChatEvent) OnDirect(a ...any) {
+	args, err := c.BindMap[t.DirectEmit](a[0])
+	if err != nil {
+		slog.Error("Chat direct: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat direct:", slog.Any("error", err))
+		return
+	}
+
+	if err := checkPermission(e.ctx, args.RoomID, t.AllowSendChat); err != nil {
+		slog.Warn("Chat direct:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-direct", err.Error())
+		return
+	}
+
+	message, recipient, err := e.ctx.Chat.Direct(
+		args.RoomID, user.ID, string(e.ctx.Socket.Id()), args.PeerID, args.Text,
+	)
+	if err != nil {
+		slog.Error("Chat direct:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-direct", err.Error())
+		return
+	}
+
+	// delivered to the socket of the recipient seat only, never to the room
+	e.ctx.Socket.Emit("chat:direct", message)
+	e.ctx.Emitter.To(s.Room(recipient.SocketID)).Emit("chat:direct", message)
+}
+
+// OnEdit changes the text of a message of the socket user
+func (e *ChatEvent) OnEdit(a ...any) {
+	args, err := c.BindMap[t.ChatEdit](a[0])
+	if err != nil {
+		slog.Error("Chat edit: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat edit:", slog.Any("error", err))
+		return
+	}
+
+	if err := checkPermission(e.ctx, args.RoomID, t.AllowSendChat); err != nil {
+		slog.Warn("Chat edit:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-edit", err.Error())
+		return
+	}
+
+	delta, err := e.ctx.Chat.Edit(
+		args.RoomID, user.ID, string(e.ctx.Socket.Id()), args.MessageID, args.Text,
+	)
+	if err != nil {
+		slog.Error("Chat edit:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-edit", err.Error())
+		return
+	}
+
+	e.ctx.Emitter.To(s.Room(args.RoomID)).Emit("chat:edited", delta)
+}
+
+// OnDelete removes a message of the socket user, or any message for hosts
+func (e *ChatEvent) OnDelete(a ...any) {
+	args, err := c.BindMap[t.ChatDelete](a[0])
+	if err != nil {
+		slog.Error("Chat delete: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat delete:", slog.Any("error", err))
+		return
+	}
+
+	delta, err := e.ctx.Chat.Delete(args.RoomID, user.ID, string(e.ctx.Socket.Id()), args.MessageID)
+	if err != nil {
+		slog.Error("Chat delete:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-delete", err.Error())
+		return
+	}
+
+	e.ctx.Emitter.To(s.Room(args.RoomID)).Emit("chat:deleted", delta)
+}
+
+// OnReact toggles the reaction of the socket user to a message
+func (e *ChatEvent) OnReact(a ...any) {
+	args, err := c.BindMap[t.ChatReact](a[0])
+	if err != nil {
+		slog.Error("Chat react: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Chat react:", slog.Any("error", err))
+		return
+	}
+
+	if err := checkPermission(e.ctx, args.RoomID, t.AllowReaction); err != nil {
+		slog.Warn("Chat react:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-react", err.Error())
+		return
+	}
+
+	delta, err := e.ctx.Chat.React(
+		args.RoomID, user.ID, string(e.ctx.Socket.Id()), args.MessageID, args.Emoji,
+	)
+	if err != nil {
+		slog.Error("Chat react:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:chat-react", err.Error())
+		return
+	}
+
+	e.ctx.Emitter.To(s.Room(args.RoomID)).Emit("chat:reacted", delta)
 }
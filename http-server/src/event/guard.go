@@ -0,0 +1,68 @@
+package event
+
+import (
+	"errors"
+	"log/slog"
+	"pry-teams/src/lib"
+	c "pry-teams/src/lib/common"
+	t "pry-teams/src/types"
+
+	"github.com/zishang520/engine.io/v2/events"
+)
+
+// HostOnly wraps the listener and only runs it when the socket user
+// is one of the hosts of the room in the event payload
+func HostOnly(ctx *lib.SocketContext, event string, listener events.Listener) events.Listener {
+	return func(a ...any) {
+		if len(a) == 0 {
+			slog.Error("HostOnly: Invalid argument", slog.String("event", event))
+			return
+		}
+
+		args, err := c.BindMap[t.Emit](a[0])
+		if err != nil {
+			slog.Error("HostOnly: Invalid argument", slog.String("event", event))
+			return
+		}
+
+		var user t.UserResponse
+		if err := user.GetFromSocket(ctx.Socket); err != nil {
+			forbidden(ctx, event, args.RoomID, err)
+			return
+		}
+
+		// guests never get a host role, checked here as well so a
+		// guest socket can't reach the host events at all
+		host := false
+		if !user.Guest {
+			if host, err = ctx.Room.IsHost(args.RoomID, user.ID); err != nil {
+				forbidden(ctx, event, args.RoomID, err)
+				return
+			}
+		}
+
+		if !host {
+			slog.Warn("HostOnly: Forbidden",
+				slog.String("event", event),
+				slog.String("room", args.RoomID),
+				slog.String("user", user.ID),
+			)
+			forbidden(ctx, event, args.RoomID, t.ErrForbidden)
+			return
+		}
+
+		listener(a...)
+	}
+}
+
+func forbidden(ctx *lib.SocketContext, event, roomId string, err error) {
+	if !errors.Is(err, t.ErrForbidden) {
+		slog.Error("HostOnly:", slog.String("event", event), slog.Any("error", err))
+	}
+
+	ctx.Socket.Emit("error:forbidden", t.ErrorEmit{
+		Event:   event,
+		RoomID:  roomId,
+		Message: t.ErrForbidden.Error(),
+	})
+}
@@ -24,14 +24,14 @@ func (h *HostEvent) OnMuteUser(a ...any) {
 		return
 	}
 
-	err = h.ctx.People.ToggleMuted(args.PeerID, c.Ptr("true"))
+	err = h.ctx.People.ToggleMuted(args.RoomID, args.PeerID, c.Ptr("true"))
 	if err != nil {
 		slog.Error("Mute user:", slog.Any("error", err))
 		h.ctx.Socket.Emit("error:mute-user", err.Error())
 		return
 	}
 
-	h.ctx.Socket.To(s.Room(args.RoomID)).Emit("host:muted-user", args.PeerID)
+	h.ctx.Broadcast(s.Room(args.RoomID)).Emit("host:muted-user", args.PeerID)
 }
 
 func (h *HostEvent) OnRemoveUser(a ...any) {
@@ -40,7 +40,30 @@ func (h *HostEvent) OnRemoveUser(a ...any) {
 		slog.Error("Remove user: Invalid argument")
 		return
 	}
-	h.ctx.Socket.To(s.Room(args.RoomID)).Emit("host:removed-user", args.PeerID)
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(h.ctx.Socket); err != nil {
+		slog.Error("Remove user:", slog.Any("error", err))
+		return
+	}
+
+	devices, count, err := h.ctx.People.Remove(args.RoomID, user.ID, args.PeerID)
+	if err != nil {
+		slog.Error("Remove user:", slog.Any("error", err))
+		h.ctx.Socket.Emit("error:remove-user", err.Error())
+		return
+	}
+
+	// every device of the user is removed, notified before leaving the room
+	for _, device := range devices {
+		h.ctx.Broadcast(s.Room(args.RoomID)).Emit("host:removed-user", device.PeerID)
+		h.ctx.Emitter.In(s.Room(device.SocketID)).SocketsLeave(s.Room(args.RoomID))
+		h.ctx.Broadcast(s.Room(args.RoomID)).Emit("room:leave", device.PeerID)
+	}
+
+	h.ctx.Emitter.To(s.Room(args.RoomID)).Emit("room:count", count)
+
+	CheckHostPresence(h.ctx, args.RoomID)
 }
 
 func (h *HostEvent) OnRemoveScreen(a ...any) {
@@ -49,13 +72,15 @@ func (h *HostEvent) OnRemoveScreen(a ...any) {
 		slog.Error("Remove screen: Invalid argument")
 		return
 	}
-	h.ctx.Socket.To(s.Room(args.RoomID)).Emit("host:removed-user-shared-screen")
+	h.ctx.Broadcast(s.Room(args.RoomID)).Emit("host:removed-user-shared-screen")
 }
 
 func (h *HostEvent) OnChangeControl(a ...any) {
-	args, err := c.BindMap[t.ControlEmit](a[0])
+	// the socket payload goes through the same validation as the http one
+	args, err := c.BindValid[t.ControlEmit](a[0])
 	if err != nil {
-		slog.Error("Change control: Invalid argument")
+		slog.Warn("Change control: Invalid argument", slog.Any("error", err))
+		h.ctx.Socket.Emit("error:change-control", err.Error())
 		return
 	}
 
@@ -65,12 +90,59 @@ func (h *HostEvent) OnChangeControl(a ...any) {
 		return
 	}
 
-	err = h.ctx.Room.UpdateControl(args.RoomID, user.ID.String(), &args.Control)
+	control, err := h.ctx.Room.UpdateControl(args.RoomID, user.ID, &args.Control)
 	if err != nil {
 		slog.Error("Change control:", slog.Any("error", err))
 		h.ctx.Socket.Emit("error:change-control", err.Error())
 		return
 	}
 
-	h.ctx.Io.To(s.Room(args.RoomID)).Emit("user:control-changed", args.Control)
+	// the passcode and the allowlist are never sent back to the room
+	h.ctx.Emitter.To(s.Room(args.RoomID)).Emit("user:control-changed", control.Control())
+}
+
+// OnLockRoom locks or unlocks the room for new join requests, the lock
+// is part of the control sent to the room
+func (h *HostEvent) OnLockRoom(a ...any) {
+	args, err := c.BindMap[t.LockEmit](a[0])
+	if err != nil {
+		slog.Error("Lock room: Invalid argument")
+		return
+	}
+
+	control, err := h.ctx.Room.LockRoom(args.RoomID, args.Locked)
+	if err != nil {
+		slog.Error("Lock room:", slog.Any("error", err))
+		h.ctx.Socket.Emit("error:lock-room", err.Error())
+		return
+	}
+
+	h.ctx.Emitter.To(s.Room(args.RoomID)).Emit("user:control-changed", control.Control())
+}
+
+func (h *HostEvent) OnSetRole(a ...any) {
+	args, err := c.BindMap[t.RoleEmit](a[0])
+	if err != nil {
+		slog.Error("Set role: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(h.ctx.Socket); err != nil {
+		slog.Error("Set role:", slog.Any("error", err))
+		return
+	}
+
+	people, err := h.ctx.Room.SetRoleByPeer(args.RoomID, user.ID, args.PeerID, args.Role)
+	if err != nil {
+		slog.Error("Set role:", slog.Any("error", err))
+		h.ctx.Socket.Emit("error:set-role", err.Error())
+		return
+	}
+
+	args.UserID = people.UserID
+	h.ctx.Emitter.To(s.Room(args.RoomID)).Emit("user:role-changed", args)
+
+	// the last host may have been demoted
+	CheckHostPresence(h.ctx, args.RoomID)
 }
@@ -0,0 +1,17 @@
+package event
+
+import (
+	"pry-teams/src/lib"
+	t "pry-teams/src/types"
+)
+
+// checkPermission resolves the socket user and validates the action
+// against the room control before it is relayed to the room
+func checkPermission(ctx *lib.SocketContext, roomId string, permission t.Permission) error {
+	var user t.UserResponse
+	if err := user.GetFromSocket(ctx.Socket); err != nil {
+		return err
+	}
+
+	return ctx.Room.CheckPermission(roomId, user.ID, permission)
+}
@@ -7,10 +7,19 @@ import (
 	c "pry-teams/src/lib/common"
 	"pry-teams/src/model"
 	t "pry-teams/src/types"
+	"strings"
+	"time"
 
 	s "github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
 )
 
+// time given to participants to wait for a host to come back
+var hostGracePeriod = c.EnvDuration("HOST_GRACE_PERIOD", 2*time.Minute)
+
+// how long the seat of a dropped connection is kept
+var reconnectGracePeriod = c.EnvDuration("RECONNECT_GRACE_PERIOD", 30*time.Second)
+
 type RoomEvent struct {
 	ctx *lib.SocketContext
 }
@@ -27,20 +36,51 @@ func (r *RoomEvent) AskToJoin(a ...any) {
 		return
 	}
 
+	var user t.UserResponse
+	if err := user.GetFromSocket(socket); err != nil {
+		slog.Error("AskToJoin:", slog.Any("error", err))
+		return
+	}
+
+	// guests keep the name given by the host who invited them
+	if user.Guest {
+		args.User.Name = user.Name
+	}
+	args.User.Guest = user.Guest
+
+	// companion devices join with the microphone off
+	if args.User.Companion {
+		args.User.Muted = true
+	}
+
 	data := model.People{
-		RoomID:   args.RoomID,
-		SocketID: string(socket.Id()),
-		UserID:   args.User.UserID,
-		PeerID:   args.User.PeerID,
-		Name:     args.User.Name,
-		Photo:    args.User.Photo,
-		Muted:    args.User.Muted,
-		Visible:  args.User.Visible,
+		RoomID:    args.RoomID,
+		SocketID:  string(socket.Id()),
+		UserID:    user.ID,
+		PeerID:    args.User.PeerID,
+		Name:      args.User.Name,
+		Photo:     args.User.Photo,
+		Muted:     args.User.Muted,
+		Visible:   args.User.Visible,
+		Guest:     user.Guest,
+		Companion: args.User.Companion,
+		Instance:  c.InstanceID(),
 	}
 
-	accepted, err := r.ctx.Room.AskToJoin(args.RoomID, &data)
-	if errors.Is(err, t.ErrAlreadyExists) {
-		socket.Emit("user:reconnect", args.User)
+	role, err := r.ctx.Room.GetRole(args.RoomID, data.UserID)
+	if err != nil {
+		slog.Error("AskToJoin:", slog.Any("error", err))
+		return
+	}
+
+	// the role is server authoritative, never trust the client
+	args.User.Role = role
+	args.User.Host = role.IsHost()
+
+	state, previous, err := r.join(&user, &args, &data)
+	if event, ok := rejectedEvent(err); ok {
+		socket.Emit("request:rejected", data.PeerID)
+		socket.Emit(event, err.Error())
 		return
 	} else if err != nil {
 		slog.Error("OnJoin:", slog.Any("error", err))
@@ -48,45 +88,159 @@ func (r *RoomEvent) AskToJoin(a ...any) {
 	}
 
 	socket.Join(s.Room(args.RoomID))
-	if accepted {
+	switch state {
+	case t.JoinAccepted:
 		socket.Emit("request:accepted", data.PeerID)
-	} else {
-		socket.To(s.Room(args.RoomID)).Emit(
+	case t.JoinWaitingHost:
+		socket.Emit("request:waiting-host", data.PeerID)
+	case t.JoinReconnected:
+		r.ctx.Timers.Stop("reconnect:" + data.ID)
+
+		args.User.Muted = data.Muted
+		args.User.Visible = data.Visible
+
+		socket.Emit("request:accepted", data.PeerID)
+		r.ctx.Emitter.To(s.Room(args.RoomID)).Emit("user:reconnected", t.ReconnectEmit{
+			RoomID:         args.RoomID,
+			PeerID:         data.PeerID,
+			PreviousPeerID: previous,
+			User:           args.User,
+		})
+	default:
+		r.ctx.Broadcast(s.Room(args.RoomID)).Emit(
 			"request:waiting", []t.User{args.User},
 		)
 	}
 }
 
+// join takes over the seat kept for the user or asks to join the room,
+// returns the previous peer id of a seat taken over
+func (r *RoomEvent) join(user *t.UserResponse, args *t.Join, data *model.People) (t.JoinState, string, error) {
+	if user.Guest && user.RoomID != args.RoomID {
+		return "", "", t.ErrGuestRoom
+	}
+
+	// a seat kept for a dropped connection is taken over without approval
+	previous, err := r.ctx.People.Reconnect(args.RoomID, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	if previous != "" {
+		return t.JoinReconnected, previous, nil
+	}
+
+	// failed passcodes are limited per room, user and address, only
+	// passcode attempts are checked so hosts and invited are never blocked
+	address := clientAddress(r.ctx.Socket)
+	if args.Passcode != "" {
+		blocked, err := r.ctx.Room.PasscodeBlocked(args.RoomID, user.ID, address)
+		if err != nil {
+			return "", "", err
+		} else if blocked {
+			return "", "", t.ErrTooManyAttempts
+		}
+	}
+
+	args.Credentials.Email = user.Email
+	args.Credentials.EmailVerified = user.EmailVerified
+	args.Credentials.Organizations = user.Organizations
+	state, err := r.ctx.Room.AskToJoin(args.RoomID, data, &args.Credentials)
+	if errors.Is(err, t.ErrPasscode) {
+		r.ctx.Room.PasscodeFailed(args.RoomID, user.ID, address) // ignore error
+	} else if err == nil && args.Passcode != "" {
+		r.ctx.Room.PasscodeAccepted(args.RoomID, user.ID) // ignore error
+	}
+
+	return state, "", err
+}
+
+// clientAddress returns the address of the client, the reverse proxy in
+// front of the server appends the address it received to X-Forwarded-For,
+// the last entry is the one the client can't forge
+func clientAddress(socket *s.Socket) string {
+	handshake := socket.Handshake()
+
+	for name, values := range handshake.Headers {
+		if !strings.EqualFold(name, "X-Forwarded-For") || len(values) == 0 {
+			continue
+		}
+
+		entries := strings.Split(values[len(values)-1], ",")
+		if address := strings.TrimSpace(entries[len(entries)-1]); address != "" {
+			return address
+		}
+	}
+
+	return handshake.Address
+}
+
+// joinErrors maps the errors refusing a join request to a typed error event
+var joinErrors = []struct {
+	err   error
+	event string
+}{
+	{t.ErrBanned, "error:banned"},
+	{t.ErrNotStarted, "error:not-started"},
+	{t.ErrArchived, "error:archived"},
+	{t.ErrGuestRoom, "error:guest"},
+	{t.ErrAlreadyExists, "error:already-joined"},
+	{t.ErrPasscodeRequired, "error:passcode-required"},
+	{t.ErrPasscode, "error:passcode"},
+	{t.ErrTooManyAttempts, "error:too-many-attempts"},
+	{t.ErrInvite, "error:invite"},
+	{t.ErrNotInvited, "error:not-invited"},
+	{t.ErrRoomFull, "error:room-full"},
+	{t.ErrRoomLocked, "error:room-locked"},
+}
+
+func rejectedEvent(err error) (string, bool) {
+	for _, e := range joinErrors {
+		if errors.Is(err, e.err) {
+			return e.event, true
+		}
+	}
+	return "", false
+}
+
+// OnAccept admits the request waiting in the lobby of the room, the
+// listener is wrapped by HostOnly
 func (r *RoomEvent) OnAccept(a ...any) {
-	peerId, ok := a[0].(string)
-	if !ok {
+	args, err := c.BindMap[t.Emit](a[0])
+	if err != nil || args.PeerID == "" {
 		slog.Error("OnAccept: Invalid argument")
 		return
 	}
 
-	data, err := r.ctx.Room.JoinAccepted(peerId)
-	if err != nil {
+	data, err := r.ctx.Room.JoinAccepted(args.RoomID, args.PeerID)
+	if errors.Is(err, t.ErrRoomFull) {
+		// the request stays in the lobby until someone leaves
+		r.ctx.Socket.Emit("error:room-full", err.Error())
+		return
+	} else if err != nil {
 		slog.Error("OnAccept:", slog.Any("error", err))
 		return
 	}
 
-	r.ctx.Socket.To(s.Room(data.SocketID)).Emit("request:accepted", peerId)
+	r.ctx.Broadcast(s.Room(data.SocketID)).Emit("request:accepted", data.PeerID)
 }
 
+// OnReject turns away the request waiting in the lobby of the room, the
+// listener is wrapped by HostOnly
 func (r *RoomEvent) OnReject(a ...any) {
-	peerId, ok := a[0].(string)
-	if !ok {
+	args, err := c.BindMap[t.Emit](a[0])
+	if err != nil || args.PeerID == "" {
 		slog.Error("OnReject: Invalid argument")
 		return
 	}
 
-	data, err := r.ctx.Room.JoinRejected(peerId)
+	data, err := r.ctx.Room.JoinRejected(args.RoomID, args.PeerID)
 	if err != nil {
 		slog.Error("OnReject:", slog.Any("error", err))
 		return
 	}
 
-	r.ctx.Socket.To(s.Room(data.SocketID)).Emit("request:rejected", data.PeerID)
+	r.ctx.Broadcast(s.Room(data.SocketID)).Emit("request:rejected", data.PeerID)
 }
 
 func (r *RoomEvent) OnCount(a ...any) {
@@ -112,19 +266,183 @@ func (r *RoomEvent) OnJoined(a ...any) {
 		return
 	}
 
-	room, count, err := r.ctx.Room.JoinRoom(args.RoomID, args.User.PeerID)
+	var user t.UserResponse
+	if err := user.GetFromSocket(r.ctx.Socket); err != nil {
+		slog.Error("OnJoined:", slog.Any("error", err))
+		r.ctx.Socket.Emit("error:join", err.Error())
+		return
+	}
+
+	// only the socket holding a seat in the room gets its state
+	room, admitted, count, err := r.ctx.Room.JoinRoom(args.RoomID, string(r.ctx.Socket.Id()))
 	if err != nil {
 		slog.Error("OnJoined:", slog.Any("error", err))
 		r.ctx.Socket.Emit("error:join", err.Error())
+		return
 	}
 
-	r.ctx.Socket.To(s.Room(args.RoomID)).Emit("room:joined", args.User)
-	r.ctx.Io.To(s.Room(args.RoomID)).Emit("room:count", count)
-	r.ctx.Socket.Emit("user:control-changed", room.RoomControl)
+	// people waiting for the host who would have been admitted directly
+	for _, people := range admitted {
+		r.ctx.Emitter.To(s.Room(people.SocketID)).Emit("request:accepted", people.PeerID)
+	}
+
+	role, err := r.ctx.Room.GetRole(args.RoomID, user.ID)
+	if err != nil {
+		slog.Error("OnJoined:", slog.Any("error", err))
+		r.ctx.Socket.Emit("error:join", err.Error())
+		return
+	}
+
+	args.User.Role = role
+	args.User.Host = role.IsHost()
+	args.User.Guest = user.Guest
+	if user.Guest {
+		args.User.Name = user.Name
+	}
+
+	r.ctx.Broadcast(s.Room(args.RoomID)).Emit("room:joined", args.User)
+	r.ctx.Emitter.To(s.Room(args.RoomID)).Emit("room:count", count)
+	r.ctx.Socket.Emit("user:control-changed", room.RoomControl.Control())
 
 	if len(room.PeopleWaiting) > 0 {
 		r.ctx.Socket.Emit("request:waiting", room.PeopleWaiting)
 	}
 
+	messages, err := r.ctx.Chat.GetRecent(args.RoomID)
+	if err != nil {
+		slog.Error("OnJoined:", slog.Any("error", err))
+	} else if len(messages) > 0 {
+		r.ctx.Socket.Emit("chat:history", messages)
+	}
+
+	CheckHostPresence(r.ctx, args.RoomID)
+
 	slog.Info("OnJoined", slog.Any("user", args.User))
 }
+
+// CheckHostPresence starts a countdown when the room requires a host and the
+// last host has left, the meeting ends when no host came back in time. The
+// deadline is kept in the database so the host may return on any instance
+func CheckHostPresence(ctx *lib.SocketContext, roomId string) {
+	key := "host-grace:" + roomId
+	room := s.Room(roomId)
+
+	waiting, err := ctx.Room.WaitingForHost(roomId)
+	if err != nil {
+		slog.Error("CheckHostPresence:", slog.Any("error", err))
+		return
+	}
+
+	if !waiting {
+		ctx.Timers.Stop(key)
+		stopped, err := ctx.Room.StopHostGrace(roomId)
+		if err != nil {
+			slog.Error("CheckHostPresence:", slog.Any("error", err))
+		} else if stopped {
+			ctx.Emitter.To(room).Emit("room:host-returned", roomId)
+		}
+		return
+	}
+
+	count, err := ctx.Room.CountPeople(roomId)
+	if err != nil || count == 0 {
+		ctx.Timers.Stop(key)
+		ctx.Room.StopHostGrace(roomId) // ignore error
+		return
+	}
+
+	deadline, started, err := ctx.Room.StartHostGrace(roomId, hostGracePeriod)
+	if err != nil {
+		slog.Error("CheckHostPresence:", slog.Any("error", err))
+		return
+	}
+
+	if started {
+		ctx.Emitter.To(room).Emit("room:host-left", t.CountdownEmit{
+			RoomID:   roomId,
+			Seconds:  int64(hostGracePeriod.Seconds()),
+			Deadline: deadline.UnixMilli(),
+		})
+	}
+
+	// every instance seeing the countdown watches the deadline,
+	// the first one to expire it ends the meeting
+	if ctx.Timers.Has(key) {
+		return
+	}
+
+	ctx.Timers.Start(key, time.Until(deadline), func() {
+		waiting, err := ctx.Room.WaitingForHost(roomId)
+		if err != nil || !waiting {
+			return
+		}
+
+		expired, err := ctx.Room.ExpireHostGrace(roomId)
+		if err != nil || !expired {
+			return
+		}
+
+		if err := ctx.Room.EndMeeting(roomId); err != nil {
+			slog.Error("CheckHostPresence:", slog.Any("error", err))
+			return
+		}
+
+		ctx.Emitter.To(room).Emit("room:ended", roomId)
+		ctx.Emitter.In(room).SocketsLeave(room)
+	})
+}
+
+// DropPeople leaves the people removed with a stopped instance from
+// their rooms, their sockets are already gone
+func DropPeople(ctx *lib.SocketContext, people []model.People) {
+	rooms := map[string]bool{}
+	for _, user := range people {
+		ctx.Emitter.To(s.Room(user.RoomID)).Emit("room:leave", user.PeerID)
+		rooms[user.RoomID] = true
+	}
+
+	for roomId := range rooms {
+		count, err := ctx.Room.CountPeople(roomId)
+		if err != nil {
+			slog.Error("DropPeople:", slog.Any("error", err))
+			continue
+		}
+
+		ctx.Emitter.To(s.Room(roomId)).Emit("room:count", count)
+		CheckHostPresence(ctx, roomId)
+	}
+}
+
+// KeepSeat keeps the seat of the disconnected socket while the user
+// reconnects, the room only sees them leave when the grace period ends
+func KeepSeat(ctx *lib.SocketContext) {
+	user, err := ctx.People.KeepSeat(string(ctx.Socket.Id()))
+	if err != nil {
+		slog.Error("Disconnect:", slog.Any("error", err))
+		return
+	}
+
+	room := s.Room(user.RoomID)
+	ctx.Socket.Leave(room)
+	ctx.Broadcast(room).Emit("user:reconnecting", t.Emit{
+		RoomID: user.RoomID,
+		PeerID: user.PeerID,
+	})
+
+	// the seat may be taken over on another instance, the
+	// socket id tells whether it is still the seat kept here
+	ctx.Timers.Start("reconnect:"+user.ID, reconnectGracePeriod, func() {
+		user, count, err := ctx.People.DropSeat(user.ID, user.SocketID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return
+		} else if err != nil {
+			slog.Error("Disconnect:", slog.Any("error", err))
+			return
+		}
+
+		ctx.Emitter.To(room).Emit("room:leave", user.PeerID)
+		ctx.Emitter.To(room).Emit("room:count", count)
+
+		CheckHostPresence(ctx, user.RoomID)
+	})
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"log/slog"
+	"pry-teams/src/lib"
+	"pry-teams/src/lib/auth"
+	c "pry-teams/src/lib/common"
+	t "pry-teams/src/types"
+	"time"
+)
+
+// how long before the token expires the client is asked to refresh it
+var sessionWarning = c.EnvDuration("SESSION_EXPIRY_WARNING", 2*time.Minute)
+
+type SessionEvent struct {
+	ctx *lib.SocketContext
+}
+
+func NewSessionEvent(ctx *lib.SocketContext) *SessionEvent {
+	return &SessionEvent{ctx: ctx}
+}
+
+// Watch schedules the expiry warning and the disconnect of the socket
+// when the token of the socket user expires
+func (e *SessionEvent) Watch() {
+	var user t.UserResponse
+	if err := user.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("Session:", slog.Any("error", err))
+		return
+	}
+
+	StopSession(e.ctx)
+	if user.ExpiresAt.IsZero() {
+		return
+	}
+
+	id := string(e.ctx.Socket.Id())
+	remaining := time.Until(user.ExpiresAt)
+
+	e.ctx.Timers.Start("session-warning:"+id, max(remaining-sessionWarning, 0), func() {
+		e.ctx.Socket.Emit("auth:expiring", sessionEmit(user.ExpiresAt))
+	})
+
+	// the disconnect listener removes the people row as usual
+	e.ctx.Timers.Start("session-expiry:"+id, max(remaining, 0), func() {
+		slog.Info("Session expired", slog.String("socket", id), slog.String("user", user.ID))
+		e.ctx.Socket.Emit("auth:expired")
+		e.ctx.Socket.Disconnect(true)
+	})
+}
+
+// OnRefresh renews the session with a new token of the same user
+func (e *SessionEvent) OnRefresh(a ...any) {
+	token, ok := a[0].(string)
+	if !ok {
+		args, err := c.BindMap[t.Refresh](a[0])
+		if err != nil {
+			slog.Error("OnRefresh: Invalid argument")
+			return
+		}
+		token = args.Token
+	}
+
+	var current t.UserResponse
+	if err := current.GetFromSocket(e.ctx.Socket); err != nil {
+		slog.Error("OnRefresh:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:auth", err.Error())
+		return
+	}
+
+	user, err := auth.AuthenticateSocket(token)
+	if err != nil {
+		slog.Warn("OnRefresh:", slog.Any("error", err))
+		e.ctx.Socket.Emit("error:auth", t.ErrUnauthorized.Error())
+		return
+	}
+
+	// the socket identity can't change, a guest can't become someone else
+	if user.ID != current.ID {
+		e.ctx.Socket.Emit("error:auth", t.ErrUnauthorized.Error())
+		return
+	}
+
+	e.ctx.Socket.SetData(map[string]any{"user": user})
+	e.Watch()
+
+	e.ctx.Socket.Emit("auth:refreshed", sessionEmit(user.ExpiresAt))
+}
+
+func sessionEmit(expiresAt time.Time) t.SessionEmit {
+	if expiresAt.IsZero() {
+		return t.SessionEmit{}
+	}
+
+	return t.SessionEmit{
+		Seconds:   int64(max(time.Until(expiresAt), 0).Seconds()),
+		ExpiresAt: expiresAt.UnixMilli(),
+	}
+}
+
+// StopSession cancels the session timers of the socket
+func StopSession(ctx *lib.SocketContext) {
+	id := string(ctx.Socket.Id())
+	ctx.Timers.Stop("session-warning:" + id)
+	ctx.Timers.Stop("session-expiry:" + id)
+}
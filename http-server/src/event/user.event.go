@@ -24,14 +24,17 @@ func (u *UserEvent) OnLeave(a ...any) {
 		return
 	}
 
-	count, err := u.ctx.People.Leave(args.RoomID, args.PeerID)
+	// only the seat of the socket leaves, whatever peer id was sent
+	seat, count, err := u.ctx.People.Leave(args.RoomID, string(u.ctx.Socket.Id()))
 	if err != nil {
-		// ignore error when leave room
 		slog.Error("OnLeave:", slog.Any("error", err))
+		return
 	}
 
-	u.ctx.Io.To(s.Room(args.RoomID)).Emit("room:count", count)
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("room:leave", args.PeerID)
+	u.ctx.Emitter.To(s.Room(args.RoomID)).Emit("room:count", count)
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("room:leave", seat.PeerID)
+
+	CheckHostPresence(u.ctx, args.RoomID)
 
 	slog.Info("OnLeave", slog.Any("id", u.ctx.Socket.Id()))
 }
@@ -43,7 +46,20 @@ func (u *UserEvent) OnReaction(a ...any) {
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:reacted", args.Reaction)
+	// only people seated in the room react
+	if _, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id())); err != nil {
+		slog.Warn("OnReaction:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:reaction", err.Error())
+		return
+	}
+
+	if err := checkPermission(u.ctx, args.RoomID, t.AllowReaction); err != nil {
+		slog.Warn("OnReaction:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:reaction", err.Error())
+		return
+	}
+
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:reacted", args.Reaction)
 }
 
 func (u *UserEvent) ToggleAudio(a ...any) {
@@ -53,14 +69,31 @@ func (u *UserEvent) ToggleAudio(a ...any) {
 		return
 	}
 
-	err = u.ctx.People.ToggleMuted(args.PeerID, nil)
+	// only the seat of the socket is toggled, whatever peer id was sent
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
+	if err != nil {
+		slog.Error("ToggleAudio:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:toggle-audio", err.Error())
+		return
+	}
+
+	// muting is always allowed, only unmute requires permission
+	if people.Muted {
+		if err := checkPermission(u.ctx, args.RoomID, t.AllowMicrophone); err != nil {
+			slog.Warn("ToggleAudio:", slog.Any("error", err))
+			u.ctx.Socket.Emit("error:toggle-audio", err.Error())
+			return
+		}
+	}
+
+	err = u.ctx.People.ToggleMuted(args.RoomID, people.PeerID, nil)
 	if err != nil {
 		slog.Error("ToggleAudio:", slog.Any("error", err))
 		u.ctx.Socket.Emit("error:toggle-audio", err.Error())
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:toggled-audio", args.PeerID)
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:toggled-audio", people.PeerID)
 }
 
 func (u *UserEvent) ToggleVideo(a ...any) {
@@ -70,14 +103,31 @@ func (u *UserEvent) ToggleVideo(a ...any) {
 		return
 	}
 
-	err = u.ctx.People.ToggleVisible(args.PeerID, nil)
+	// only the seat of the socket is toggled, whatever peer id was sent
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
+	if err != nil {
+		slog.Error("ToggleVideo:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:toggle-video", err.Error())
+		return
+	}
+
+	// turning off the camera is always allowed
+	if !people.Visible {
+		if err := checkPermission(u.ctx, args.RoomID, t.AllowVideo); err != nil {
+			slog.Warn("ToggleVideo:", slog.Any("error", err))
+			u.ctx.Socket.Emit("error:toggle-video", err.Error())
+			return
+		}
+	}
+
+	err = u.ctx.People.ToggleVisible(args.RoomID, people.PeerID, nil)
 	if err != nil {
 		slog.Error("ToggleVideo:", slog.Any("error", err))
 		u.ctx.Socket.Emit("error:toggle-video", err.Error())
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:toggled-video", args.PeerID)
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:toggled-video", people.PeerID)
 }
 
 func (u *UserEvent) ShareScreen(a ...any) {
@@ -87,7 +137,21 @@ func (u *UserEvent) ShareScreen(a ...any) {
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:shared-screen", args.PeerID)
+	// only the seat of the socket shares, whatever peer id was sent
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
+	if err != nil {
+		slog.Warn("ShareScreen:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:share-screen", err.Error())
+		return
+	}
+
+	if err := checkPermission(u.ctx, args.RoomID, t.AllowShareScreen); err != nil {
+		slog.Warn("ShareScreen:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:share-screen", err.Error())
+		return
+	}
+
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:shared-screen", people.PeerID)
 }
 
 func (u *UserEvent) StopShareScreen(a ...any) {
@@ -97,7 +161,15 @@ func (u *UserEvent) StopShareScreen(a ...any) {
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:stopped-screen-share", args.PeerID)
+	// hosts use host:remove-shared-screen to stop the screen of someone else
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
+	if err != nil {
+		slog.Warn("StopShareScreen:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:stop-share-screen", err.Error())
+		return
+	}
+
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:stopped-screen-share", people.PeerID)
 }
 
 func (u *UserEvent) OnDisableMicrophone(a ...any) {
@@ -107,14 +179,21 @@ func (u *UserEvent) OnDisableMicrophone(a ...any) {
 		return
 	}
 
-	err = u.ctx.People.ToggleMuted(args.PeerID, c.Ptr("true"))
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
 	if err != nil {
 		slog.Error("OnDisableMicrophone:", slog.Any("error", err))
 		u.ctx.Socket.Emit("error:disable-microphone", err.Error())
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:disable-microphone", args.PeerID)
+	err = u.ctx.People.ToggleMuted(args.RoomID, people.PeerID, c.Ptr("true"))
+	if err != nil {
+		slog.Error("OnDisableMicrophone:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:disable-microphone", err.Error())
+		return
+	}
+
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:disable-microphone", people.PeerID)
 }
 
 func (u *UserEvent) OnDisableCamera(a ...any) {
@@ -124,12 +203,87 @@ func (u *UserEvent) OnDisableCamera(a ...any) {
 		return
 	}
 
-	err = u.ctx.People.ToggleVisible(args.PeerID, c.Ptr("false"))
+	people, err := u.ctx.People.GetSeat(args.RoomID, string(u.ctx.Socket.Id()))
+	if err != nil {
+		slog.Error("OnDisableCamera:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:disable-camera", err.Error())
+		return
+	}
+
+	err = u.ctx.People.ToggleVisible(args.RoomID, people.PeerID, c.Ptr("false"))
 	if err != nil {
 		slog.Error("OnDisableCamera:", slog.Any("error", err))
 		u.ctx.Socket.Emit("error:disable-camera", err.Error())
 		return
 	}
 
-	u.ctx.Socket.To(s.Room(args.RoomID)).Emit("user:disable-camera", args.PeerID)
+	u.ctx.Broadcast(s.Room(args.RoomID)).Emit("user:disable-camera", people.PeerID)
+}
+
+// OnDevices lists the devices of the socket user in the room
+func (u *UserEvent) OnDevices(a ...any) {
+	args, err := c.BindMap[t.Emit](a[0])
+	if err != nil {
+		slog.Error("OnDevices: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(u.ctx.Socket); err != nil {
+		slog.Error("OnDevices:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:devices", err.Error())
+		return
+	}
+
+	people, err := u.ctx.People.GetDevices(args.RoomID, user.ID)
+	if err != nil {
+		slog.Error("OnDevices:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:devices", err.Error())
+		return
+	}
+
+	devices := make([]t.Device, 0, len(people))
+	for _, p := range people {
+		devices = append(devices, t.Device{
+			PeerID:       p.PeerID,
+			Name:         p.Name,
+			Muted:        p.Muted,
+			Visible:      p.Visible,
+			Companion:    p.Companion,
+			Reconnecting: p.Reconnecting,
+			Current:      p.SocketID == string(u.ctx.Socket.Id()),
+		})
+	}
+
+	u.ctx.Socket.Emit("user:devices", devices)
+}
+
+// OnTransfer moves the active session of the socket user to the device
+func (u *UserEvent) OnTransfer(a ...any) {
+	args, err := c.BindMap[t.Emit](a[0])
+	if err != nil {
+		slog.Error("OnTransfer: Invalid argument")
+		return
+	}
+
+	var user t.UserResponse
+	if err := user.GetFromSocket(u.ctx.Socket); err != nil {
+		slog.Error("OnTransfer:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:transfer", err.Error())
+		return
+	}
+
+	from, to, err := u.ctx.People.Transfer(args.RoomID, user.ID, args.PeerID)
+	if err != nil {
+		slog.Error("OnTransfer:", slog.Any("error", err))
+		u.ctx.Socket.Emit("error:transfer", err.Error())
+		return
+	}
+
+	transfer := t.TransferEmit{RoomID: args.RoomID, UserID: user.ID, To: to.PeerID}
+	if from != nil {
+		transfer.From = from.PeerID
+	}
+
+	u.ctx.Emitter.To(s.Room(args.RoomID)).Emit("user:transferred", transfer)
 }
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"pry-teams/src/types"
+	"testing"
+)
+
+func TestAuthenticateGuest(t *testing.T) {
+	t.Setenv("GUEST_TOKEN_SECRET", testSecret)
+
+	valid, _, err := SignGuest("abc-defg-hij", "Guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guest := func() map[string]any {
+		return claims(map[string]any{
+			"sub":     types.GuestPrefix + "1",
+			"aud":     guestAudience,
+			"iss":     guestIssuer,
+			"room_id": "abc-defg-hij",
+		})
+	}
+
+	forge := func(overrides map[string]any) map[string]any {
+		claims := guest()
+		for key, value := range overrides {
+			if value == nil {
+				delete(claims, key)
+				continue
+			}
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "signed", token: valid},
+		{name: "other secret", token: sign(t, "HS256", "", "other-secret", guest()), err: ErrInvalidToken},
+		{name: "alg none", token: sign(t, "none", "", "", guest()), err: ErrInvalidToken},
+		{
+			name:  "user id",
+			token: sign(t, "HS256", "", testSecret, forge(map[string]any{"sub": "user-1"})),
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "no room",
+			token: sign(t, "HS256", "", testSecret, forge(map[string]any{"room_id": nil})),
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "user audience",
+			token: sign(t, "HS256", "", testSecret, forge(map[string]any{"aud": testAudience})),
+			err:   ErrAudience,
+		},
+		{
+			name:  "other issuer",
+			token: sign(t, "HS256", "", testSecret, forge(map[string]any{"iss": testIssuer})),
+			err:   ErrIssuer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := AuthenticateGuest(tt.token)
+
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if !user.Guest || user.RoomID != "abc-defg-hij" || user.Name != "Guest" {
+					t.Errorf("unexpected guest %+v", user)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestGuestDisabled(t *testing.T) {
+	t.Setenv("GUEST_TOKEN_SECRET", "")
+
+	if _, _, err := SignGuest("abc-defg-hij", "Guest"); !errors.Is(err, ErrGuestDisabled) {
+		t.Errorf("got error %v, want %v", err, ErrGuestDisabled)
+	}
+
+	token := sign(t, "HS256", "", "", claims(nil))
+	if _, err := AuthenticateGuest(token); !errors.Is(err, ErrGuestDisabled) {
+		t.Errorf("got error %v, want %v", err, ErrGuestDisabled)
+	}
+}
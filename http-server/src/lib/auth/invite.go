@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	c "pry-teams/src/lib/common"
+	"strings"
+)
+
+var ErrInviteDisabled = errors.New("invite links are not configured")
+
+func inviteSecret() (string, error) {
+	secret := c.Env("INVITE_TOKEN_SECRET")
+	if secret == "" {
+		return "", ErrInviteDisabled
+	}
+	return secret, nil
+}
+
+func inviteSignature(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("invite:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignInvite returns the token of the invite, the limits of the
+// invite are kept in the database so it can be revoked
+func SignInvite(id string) (string, error) {
+	secret, err := inviteSecret()
+	if err != nil {
+		return "", err
+	}
+
+	return id + "." + inviteSignature(secret, id), nil
+}
+
+// ParseInvite verifies the signature and returns the invite id
+func ParseInvite(token string) (string, error) {
+	secret, err := inviteSecret()
+	if err != nil {
+		return "", err
+	}
+
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", ErrInvalidToken
+	}
+
+	expected := inviteSignature(secret, id)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", ErrInvalidToken
+	}
+
+	return id, nil
+}
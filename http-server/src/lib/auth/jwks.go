@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minimum time between two fetches triggered by an unknown key id
+const refetchInterval = time.Minute
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet caches the public keys published on a jwks endpoint, stale keys
+// are kept when a refresh fails so an issuer outage doesn't reject tokens
+type KeySet struct {
+	url     string
+	issuer  string
+	headers map[string]string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func NewKeySet(url string, headers map[string]string, ttl time.Duration) *KeySet {
+	return &KeySet{
+		url:     url,
+		headers: headers,
+		ttl:     ttl,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		keys:    map[string]crypto.PublicKey{},
+	}
+}
+
+func (k *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[kid]
+	if ok && time.Since(k.fetchedAt) < k.ttl {
+		return key, nil
+	}
+
+	// unknown key id, don't hammer the endpoint with forged tokens
+	if !ok && time.Since(k.fetchedAt) < refetchInterval {
+		return nil, ErrUnverifiable
+	}
+
+	keys, err := k.fetch()
+	k.fetchedAt = time.Now()
+	if err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnverifiable, err)
+	}
+
+	k.keys = keys
+	if key, ok = k.keys[kid]; !ok {
+		return nil, ErrUnverifiable
+	}
+
+	return key, nil
+}
+
+// NewDiscoveryKeySet finds the jwks endpoint in the openid configuration
+// of the issuer, the lookup is deferred to the first fetch
+func NewDiscoveryKeySet(issuer string, ttl time.Duration) *KeySet {
+	keys := NewKeySet("", nil, ttl)
+	keys.issuer = strings.TrimSuffix(issuer, "/")
+	return keys
+}
+
+func (k *KeySet) fetch() (map[string]crypto.PublicKey, error) {
+	if k.url == "" {
+		var config struct {
+			JwksURI string `json:"jwks_uri"`
+		}
+		if err := k.get(k.issuer+"/.well-known/openid-configuration", &config); err != nil {
+			return nil, err
+		}
+		if config.JwksURI == "" {
+			return nil, fmt.Errorf("jwks: no jwks_uri in the openid configuration")
+		}
+		k.url = config.JwksURI
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := k.get(k.url, &body); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, key := range body.Keys {
+		if pub, err := key.publicKey(); err == nil {
+			keys[key.Kid] = pub
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *KeySet) get(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range k.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("jwks: unsupported curve %q", j.Crv)
+		}
+
+		x, err := decodeInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("jwks: unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
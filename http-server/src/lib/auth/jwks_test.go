@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeySetUnknownKid(t *testing.T) {
+	hits := 0
+	server := newTestKeys(t).jwks(t, &hits)
+	keys := NewKeySet(server.URL, nil, time.Hour)
+
+	if _, err := keys.Key("rsa"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// forged key ids must not trigger a fetch each
+	for range 3 {
+		if _, err := keys.Key("unknown"); !errors.Is(err, ErrUnverifiable) {
+			t.Errorf("got error %v, want %v", err, ErrUnverifiable)
+		}
+	}
+
+	if hits != 1 {
+		t.Errorf("got %d fetches, want 1", hits)
+	}
+
+	// a rotated key is picked up once the refetch interval passed
+	keys.fetchedAt = time.Now().Add(-refetchInterval)
+	if _, err := keys.Key("unknown"); !errors.Is(err, ErrUnverifiable) {
+		t.Errorf("got error %v, want %v", err, ErrUnverifiable)
+	}
+
+	if hits != 2 {
+		t.Errorf("got %d fetches, want 2", hits)
+	}
+}
+
+func TestKeySetKeepsStaleKeys(t *testing.T) {
+	server := newTestKeys(t).jwks(t, nil)
+	keys := NewKeySet(server.URL, nil, time.Minute)
+
+	if _, err := keys.Key("ec"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the issuer is down once the cached keys expire
+	server.Close()
+	keys.fetchedAt = time.Now().Add(-time.Hour)
+
+	if _, err := keys.Key("ec"); err != nil {
+		t.Errorf("stale key rejected: %v", err)
+	}
+
+	keys.fetchedAt = time.Now().Add(-time.Hour)
+	if _, err := keys.Key("rotated"); !errors.Is(err, ErrUnverifiable) {
+		t.Errorf("got error %v, want %v", err, ErrUnverifiable)
+	}
+}
+
+func TestDiscoveryKeySet(t *testing.T) {
+	jwks := newTestKeys(t).jwks(t, nil)
+
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"jwks_uri": jwks.URL})
+	}))
+	t.Cleanup(issuer.Close)
+
+	keys := NewDiscoveryKeySet(issuer.URL+"/", time.Hour)
+	if _, err := keys.Key("rsa"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret   = "test-secret"
+	testAudience = "pry-teams"
+	testIssuer   = "https://issuer.test"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func encodeInt(i *big.Int, size int) string {
+	return encode(i.FillBytes(make([]byte, size)))
+}
+
+// jwks serves the public keys as "rsa" and "ec", hits counts the requests
+func (k *testKeys) jwks(t *testing.T, hits *int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			*hits++
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{
+				"kid": "rsa", "kty": "RSA",
+				"n": encode(k.rsa.N.Bytes()),
+				"e": encode(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kid": "ec", "kty": "EC", "crv": "P-256",
+				"x": encodeInt(k.ec.X, 32),
+				"y": encodeInt(k.ec.Y, 32),
+			},
+		}})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// sign mints a token, the key is the hmac secret, an rsa or an ec private key
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+
+	head, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	signed := encode(head) + "." + encode(body)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case string:
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + encode(signature)
+}
+
+func claims(overrides map[string]any) map[string]any {
+	now := time.Now()
+	claims := map[string]any{
+		"sub": "user-1",
+		"aud": testAudience,
+		"iss": testIssuer,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+
+	return claims
+}
+
+func TestVerifierVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	server := keys.jwks(t, nil)
+	verifier := NewVerifier(testSecret, NewKeySet(server.URL, nil, time.Hour), testAudience, testIssuer)
+
+	past := time.Now().Add(-time.Hour).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "hs256", token: sign(t, "HS256", "", testSecret, claims(nil))},
+		{name: "rs256", token: sign(t, "RS256", "rsa", keys.rsa, claims(nil))},
+		{name: "es256", token: sign(t, "ES256", "ec", keys.ec, claims(nil))},
+		{
+			name:  "audience list",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"aud": []string{"other", testAudience}})),
+		},
+		{
+			name:  "expired within leeway",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"exp": time.Now().Add(-leeway / 2).Unix()})),
+		},
+
+		{name: "malformed", token: "not.a-token", err: ErrInvalidToken},
+		{name: "wrong secret", token: sign(t, "HS256", "", "other-secret", claims(nil)), err: ErrInvalidToken},
+		{name: "alg none", token: sign(t, "none", "", "", claims(nil)), err: ErrInvalidToken},
+		{name: "alg hs384", token: sign(t, "HS384", "", testSecret, claims(nil)), err: ErrInvalidToken},
+		{name: "rsa key as es256", token: sign(t, "ES256", "rsa", keys.rsa, claims(nil)), err: ErrInvalidToken},
+		{name: "ec key as rs256", token: sign(t, "RS256", "ec", keys.ec, claims(nil)), err: ErrInvalidToken},
+		{name: "unknown kid", token: sign(t, "RS256", "rotated", keys.rsa, claims(nil)), err: ErrUnverifiable},
+		{
+			name:  "other rsa key",
+			token: sign(t, "RS256", "rsa", newTestKeys(t).rsa, claims(nil)),
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "expired",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"exp": past})),
+			err:   ErrExpired,
+		},
+		{
+			name:  "no expiry",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"exp": nil})),
+			err:   ErrExpired,
+		},
+		{
+			name:  "not yet valid",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"nbf": future})),
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "bad audience",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"aud": "other"})),
+			err:   ErrAudience,
+		},
+		{
+			name:  "no audience",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"aud": nil})),
+			err:   ErrAudience,
+		},
+		{
+			name:  "bad issuer",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"iss": "https://evil.test"})),
+			err:   ErrIssuer,
+		},
+		{
+			name:  "no subject",
+			token: sign(t, "HS256", "", testSecret, claims(map[string]any{"sub": nil})),
+			err:   ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if got.Subject != "user-1" {
+					t.Errorf("got subject %q, want %q", got.Subject, "user-1")
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifierTampered(t *testing.T) {
+	verifier := NewVerifier(testSecret, nil, testAudience, testIssuer)
+	token := sign(t, "HS256", "", testSecret, claims(nil))
+
+	forged, _ := json.Marshal(claims(map[string]any{"sub": "admin"}))
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + encode(forged) + "." + parts[2]
+
+	if _, err := verifier.Verify(tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifierRequiresAudience(t *testing.T) {
+	verifier := NewVerifier(testSecret, nil, "", testIssuer)
+	token := sign(t, "HS256", "", testSecret, claims(nil))
+
+	if _, err := verifier.Verify(token); !errors.Is(err, ErrAudience) {
+		t.Errorf("got error %v, want %v", err, ErrAudience)
+	}
+}
+
+func TestVerifierWithoutKeys(t *testing.T) {
+	keys := newTestKeys(t)
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+	}{
+		{
+			name:     "hs256 without secret",
+			verifier: NewVerifier("", nil, testAudience, testIssuer),
+			token:    sign(t, "HS256", "", "", claims(nil)),
+		},
+		{
+			name:     "rs256 without key set",
+			verifier: NewVerifier(testSecret, nil, testAudience, testIssuer),
+			token:    sign(t, "RS256", "rsa", keys.rsa, claims(nil)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.verifier.Verify(tt.token); !errors.Is(err, ErrUnverifiable) {
+				t.Errorf("got error %v, want %v", err, ErrUnverifiable)
+			}
+		})
+	}
+}
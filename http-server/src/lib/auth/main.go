@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	c "pry-teams/src/lib/common"
+	"pry-teams/src/types"
+	"sync"
+)
+
+// Provider authenticates the access token sent by the client
+type Provider interface {
+	Name() string
+	Authenticate(token string) (*types.AuthUser, error)
+}
+
+var (
+	provider Provider
+	once     sync.Once
+)
+
+// New creates the provider by name, supabase is used by default
+func New(name string) (Provider, error) {
+	switch name {
+	case "", "supabase":
+		return NewSupabaseProvider()
+	case "oidc":
+		return NewOIDCProvider()
+	case "static":
+		return NewStaticProviderFromFile(c.Env("AUTH_STATIC_USERS"))
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q", name)
+	}
+}
+
+// Default returns the provider configured with AUTH_PROVIDER
+func Default() Provider {
+	once.Do(func() {
+		var err error
+		provider, err = New(c.Env("AUTH_PROVIDER"))
+		if err != nil {
+			log.Fatalf("Initialize auth provider error: %v", err)
+		}
+	})
+	return provider
+}
+
+// Authenticate verifies the access token with the configured provider
+// and returns the user it was issued for
+func Authenticate(token string) (*types.AuthUser, error) {
+	return Default().Authenticate(token)
+}
+
+// AuthenticateSocket also accepts guest tokens, they are checked first
+// because they are verified locally
+func AuthenticateSocket(token string) (*types.AuthUser, error) {
+	if user, err := AuthenticateGuest(token); err == nil {
+		return user, nil
+	}
+	return Authenticate(token)
+}
+
+// metadataList reads the first key holding a string or a list of strings
+func metadataList(metadata map[string]any, keys ...string) []string {
+	for _, key := range keys {
+		switch value := metadata[key].(type) {
+		case string:
+			if value != "" {
+				return []string{value}
+			}
+		case []any:
+			var list []string
+			for _, v := range value {
+				if s, ok := v.(string); ok && s != "" {
+					list = append(list, s)
+				}
+			}
+			if len(list) > 0 {
+				return list
+			}
+		}
+	}
+	return nil
+}
+
+// isTrue accepts both booleans and "true", some issuers send claims as strings
+func isTrue(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func metadataString(metadata map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := metadata[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
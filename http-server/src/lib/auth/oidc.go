@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"errors"
+	c "pry-teams/src/lib/common"
+	"pry-teams/src/types"
+	"time"
+)
+
+// OIDCProvider verifies the tokens of any openid connect issuer, or plain
+// jwt signed with a shared secret when no issuer is configured
+type OIDCProvider struct {
+	verifier *Verifier
+}
+
+func NewOIDCProvider() (*OIDCProvider, error) {
+	issuer := c.Env("OIDC_ISSUER")
+	secret := c.Env("OIDC_SECRET")
+	if issuer == "" && secret == "" {
+		return nil, errors.New("oidc: OIDC_ISSUER or OIDC_SECRET is required")
+	}
+
+	audience := c.Env("OIDC_AUDIENCE")
+	if audience == "" {
+		return nil, errors.New("oidc: OIDC_AUDIENCE is required")
+	}
+
+	ttl := c.EnvDuration("OIDC_JWKS_TTL", 10*time.Minute)
+
+	var keys *KeySet
+	if url := c.Env("OIDC_JWKS_URL"); url != "" {
+		keys = NewKeySet(url, nil, ttl)
+	} else if issuer != "" {
+		keys = NewDiscoveryKeySet(issuer, ttl)
+	}
+
+	return &OIDCProvider{
+		verifier: NewVerifier(secret, keys, audience, issuer),
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return "oidc"
+}
+
+func (p *OIDCProvider) Authenticate(token string) (*types.AuthUser, error) {
+	claims, err := p.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Username
+	}
+
+	return &types.AuthUser{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.Verified),
+		Organizations: claims.Organization,
+		Name:          name,
+		Photo:         claims.Picture,
+		Provider:      p.Name(),
+		ExpiresAt:     claims.Expiry(),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewOIDCProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string
+		secret   string
+		audience string
+		valid    bool
+	}{
+		{name: "secret", secret: testSecret, audience: testAudience, valid: true},
+		{name: "issuer", issuer: testIssuer, audience: testAudience, valid: true},
+		{name: "no audience", secret: testSecret},
+		{name: "no issuer or secret", audience: testAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OIDC_ISSUER", tt.issuer)
+			t.Setenv("OIDC_SECRET", tt.secret)
+			t.Setenv("OIDC_AUDIENCE", tt.audience)
+			t.Setenv("OIDC_JWKS_URL", "")
+
+			_, err := NewOIDCProvider()
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error %v", err)
+			} else if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestOIDCAuthenticate(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "")
+	t.Setenv("OIDC_SECRET", testSecret)
+	t.Setenv("OIDC_AUDIENCE", testAudience)
+
+	provider, err := NewOIDCProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := sign(t, "HS256", "", testSecret, claims(map[string]any{
+		"iss":                "",
+		"email":              "user@example.com",
+		"email_verified":     "true",
+		"preferred_username": "user",
+		"org_id":             "org-1",
+	}))
+
+	user, err := provider.Authenticate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.ID != "user-1" || user.Name != "user" || !user.EmailVerified || user.Organizations[0] != "org-1" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	other := sign(t, "HS256", "", testSecret, claims(map[string]any{"iss": "", "aud": "other"}))
+	if _, err := provider.Authenticate(other); !errors.Is(err, ErrAudience) {
+		t.Errorf("got error %v, want %v", err, ErrAudience)
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"pry-teams/src/types"
+)
+
+// StaticProvider maps fixed tokens to users, meant for local
+// development and tests, never for production
+type StaticProvider struct {
+	users map[string]types.AuthUser
+}
+
+func NewStaticProvider(users map[string]types.AuthUser) *StaticProvider {
+	return &StaticProvider{users: users}
+}
+
+// NewStaticProviderFromFile reads a json object of token to user, eg.
+// {"dev-token": {"id": "dev", "email": "dev@localhost", "name": "Dev"}}
+func NewStaticProviderFromFile(path string) (*StaticProvider, error) {
+	if path == "" {
+		return nil, errors.New("static: AUTH_STATIC_USERS is required")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var users map[string]types.AuthUser
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("static: %w", err)
+	}
+
+	return NewStaticProvider(users), nil
+}
+
+func (p *StaticProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticProvider) Authenticate(token string) (*types.AuthUser, error) {
+	user, ok := p.users[token]
+	if !ok || user.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	user.Provider = p.Name()
+	return &user, nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	c "pry-teams/src/lib/common"
+	"pry-teams/src/lib/database"
+	"pry-teams/src/types"
+	"strings"
+	"time"
+)
+
+type Mode string
+
+const (
+	// ModeLocal only accepts tokens verified with the secret or key set
+	ModeLocal Mode = "local"
+	// ModeRemote asks supabase for every token
+	ModeRemote Mode = "remote"
+	// ModeFallback asks supabase when the token can't be verified locally
+	ModeFallback Mode = "fallback"
+)
+
+// SupabaseProvider verifies the access tokens issued by supabase auth
+type SupabaseProvider struct {
+	mode     Mode
+	verifier *Verifier
+}
+
+func NewSupabaseProvider() (*SupabaseProvider, error) {
+	mode := Mode(c.Env("AUTH_MODE"))
+	switch mode {
+	case "":
+		mode = ModeLocal
+	case ModeLocal, ModeRemote, ModeFallback:
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", mode)
+	}
+
+	url := strings.TrimSuffix(c.Env("SUPABASE_URL"), "/")
+	jwksUrl := c.Env("SUPABASE_JWKS_URL")
+	if jwksUrl == "" {
+		jwksUrl = url + "/auth/v1/.well-known/jwks.json"
+	}
+
+	audience := c.Env("SUPABASE_JWT_AUDIENCE")
+	if audience == "" {
+		audience = "authenticated"
+	}
+
+	keys := NewKeySet(
+		jwksUrl,
+		map[string]string{"apikey": c.Env("SUPABASE_ANON_KEY")},
+		c.EnvDuration("SUPABASE_JWKS_TTL", 10*time.Minute),
+	)
+
+	return &SupabaseProvider{
+		mode: mode,
+		verifier: NewVerifier(
+			c.Env("SUPABASE_JWT_SECRET"),
+			keys,
+			audience,
+			c.Env("SUPABASE_JWT_ISSUER"),
+		),
+	}, nil
+}
+
+func (p *SupabaseProvider) Name() string {
+	return "supabase"
+}
+
+// Authenticate verifies the token depending on AUTH_MODE
+func (p *SupabaseProvider) Authenticate(token string) (*types.AuthUser, error) {
+	if p.mode == ModeRemote {
+		return p.remote(token)
+	}
+
+	claims, err := p.verifier.Verify(token)
+	if errors.Is(err, ErrUnverifiable) && p.mode == ModeFallback {
+		return p.remote(token)
+	} else if err != nil {
+		return nil, err
+	}
+
+	user := p.user(claims.Subject, claims.Email, claims.UserMetadata, claims.AppMetadata)
+	// supabase tokens don't carry the confirmation and the user metadata
+	// can't be trusted as users are able to change it, only a top level
+	// claim added by a custom access token hook verifies the email
+	user.EmailVerified = isTrue(claims.Verified) && user.Email != ""
+	user.ExpiresAt = claims.Expiry()
+
+	return user, nil
+}
+
+func (p *SupabaseProvider) remote(token string) (*types.AuthUser, error) {
+	user, err := database.Supabase().Auth.WithToken(token).GetUser()
+	if err != nil {
+		return nil, err
+	}
+
+	// supabase already validated the token, only the expiry is read
+	result := p.user(user.ID.String(), user.Email, user.UserMetadata, user.AppMetadata)
+	result.EmailVerified = user.EmailConfirmedAt != nil
+	result.ExpiresAt = unverifiedExpiry(token)
+
+	return result, nil
+}
+
+// user builds the same identity from the token claims and the supabase
+// user endpoint, organizations come from the app metadata only the
+// service role is able to change
+func (p *SupabaseProvider) user(id, email string, metadata, appMetadata map[string]any) *types.AuthUser {
+	return &types.AuthUser{
+		ID:            id,
+		Email:         email,
+		Organizations: metadataList(appMetadata, "organizations", "organization"),
+		Name:          metadataString(metadata, "name", "full_name"),
+		Photo:         metadataString(metadata, "avatar_url", "picture"),
+		Provider:      p.Name(),
+		Metadata:      metadata,
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestSupabaseLocalEmailVerified(t *testing.T) {
+	provider := &SupabaseProvider{
+		mode:     ModeLocal,
+		verifier: NewVerifier(testSecret, nil, testAudience, testIssuer),
+	}
+
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		verified bool
+	}{
+		{name: "no claim", claims: map[string]any{"email": "user@example.com"}},
+		{
+			name: "user metadata",
+			claims: map[string]any{
+				"email":         "user@example.com",
+				"user_metadata": map[string]any{"email_verified": true},
+			},
+		},
+		{
+			name:     "hook claim",
+			claims:   map[string]any{"email": "user@example.com", "email_verified": true},
+			verified: true,
+		},
+		{name: "no email", claims: map[string]any{"email_verified": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := provider.Authenticate(sign(t, "HS256", "", testSecret, claims(tt.claims)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if user.EmailVerified != tt.verified {
+				t.Errorf("got verified %v, want %v", user.EmailVerified, tt.verified)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package broadcast
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// New creates the adapter by name, the local adapter is used
+// when running a single instance
+func New(name string, db *gorm.DB, dsn string) (Adapter, error) {
+	switch name {
+	case "", "local":
+		return &LocalAdapter{}, nil
+	case "postgres":
+		return NewPostgresAdapter(db, dsn, "socket_broadcast"), nil
+	default:
+		return nil, fmt.Errorf("unknown socket adapter %q", name)
+	}
+}
+
+// LocalAdapter doesn't fan out, every socket is connected to this instance
+type LocalAdapter struct{}
+
+func (*LocalAdapter) Publish(payload []byte) error {
+	return nil
+}
+
+func (*LocalAdapter) Subscribe(handler func(payload []byte)) error {
+	return nil
+}
+
+func (*LocalAdapter) Close() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	s "github.com/zishang520/socket.io/v2/socket"
+)
+
+// Adapter fans out the packets of an Emitter to the other server instances
+type Adapter interface {
+	Publish(payload []byte) error
+	Subscribe(handler func(payload []byte)) error
+	Close() error
+}
+
+const (
+	actionEmit       = "emit"
+	actionLeave      = "leave"
+	actionDisconnect = "disconnect"
+)
+
+type packet struct {
+	Instance string          `json:"instance"`
+	Action   string          `json:"action"`
+	Rooms    []s.Room        `json:"rooms"`
+	Except   []s.Room        `json:"except,omitempty"`
+	Leave    []s.Room        `json:"leave,omitempty"`
+	Event    string          `json:"event,omitempty"`
+	Args     json.RawMessage `json:"args,omitempty"`
+	Close    bool            `json:"close,omitempty"`
+}
+
+// Emitter applies room operations on the local socket server
+// and publishes them to the other instances through the adapter
+type Emitter struct {
+	io       *s.Server
+	adapter  Adapter
+	instance string
+}
+
+func NewEmitter(io *s.Server, adapter Adapter, instance string) *Emitter {
+	return &Emitter{io: io, adapter: adapter, instance: instance}
+}
+
+// Start listens to the packets published by the other instances
+func (e *Emitter) Start() error {
+	return e.adapter.Subscribe(e.receive)
+}
+
+func (e *Emitter) Close() error {
+	return e.adapter.Close()
+}
+
+func (e *Emitter) To(room ...s.Room) *Operator {
+	return &Operator{emitter: e, rooms: room}
+}
+
+func (e *Emitter) In(room ...s.Room) *Operator {
+	return e.To(room...)
+}
+
+func (e *Emitter) publish(p packet) {
+	p.Instance = e.instance
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		slog.Error("Broadcast publish:", slog.Any("error", err))
+		return
+	}
+
+	if err := e.adapter.Publish(payload); err != nil {
+		slog.Error("Broadcast publish:", slog.Any("error", err))
+	}
+}
+
+func (e *Emitter) receive(payload []byte) {
+	var p packet
+	if err := json.Unmarshal(payload, &p); err != nil {
+		slog.Error("Broadcast receive:", slog.Any("error", err))
+		return
+	}
+
+	// packets of this instance are already applied locally
+	if p.Instance == e.instance {
+		return
+	}
+
+	local := e.io.To(p.Rooms...).Except(p.Except...)
+
+	switch p.Action {
+	case actionEmit:
+		var args []any
+		if len(p.Args) > 0 {
+			if err := json.Unmarshal(p.Args, &args); err != nil {
+				slog.Error("Broadcast receive:", slog.Any("error", err))
+				return
+			}
+		}
+		local.Emit(p.Event, args...)
+	case actionLeave:
+		local.SocketsLeave(p.Leave...)
+	case actionDisconnect:
+		local.DisconnectSockets(p.Close)
+	}
+}
+
+// Operator mirrors the socket.io broadcast operator across instances
+type Operator struct {
+	emitter *Emitter
+	rooms   []s.Room
+	except  []s.Room
+}
+
+func (o *Operator) Except(room ...s.Room) *Operator {
+	o.except = append(o.except, room...)
+	return o
+}
+
+func (o *Operator) local() *s.BroadcastOperator {
+	return o.emitter.io.To(o.rooms...).Except(o.except...)
+}
+
+func (o *Operator) Emit(ev string, args ...any) error {
+	if err := o.local().Emit(ev, args...); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	o.emitter.publish(packet{
+		Action: actionEmit,
+		Rooms:  o.rooms,
+		Except: o.except,
+		Event:  ev,
+		Args:   raw,
+	})
+
+	return nil
+}
+
+func (o *Operator) SocketsLeave(room ...s.Room) {
+	o.local().SocketsLeave(room...)
+	o.emitter.publish(packet{
+		Action: actionLeave,
+		Rooms:  o.rooms,
+		Except: o.except,
+		Leave:  room,
+	})
+}
+
+func (o *Operator) DisconnectSockets(close bool) {
+	o.local().DisconnectSockets(close)
+	o.emitter.publish(packet{
+		Action: actionDisconnect,
+		Rooms:  o.rooms,
+		Except: o.except,
+		Close:  close,
+	})
+}
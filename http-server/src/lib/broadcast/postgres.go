@@ -0,0 +1,104 @@
+package broadcast
+
+import (
+	"log/slog"
+	"pry-teams/src/model"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	// notification payloads are limited to 8000 bytes
+	maxNotifyPayload = 7500
+	// stored packets are removed once every instance had time to read them
+	storedPacketTTL = time.Minute
+	storedPrefix    = "ref:"
+)
+
+// PostgresAdapter fans out packets with LISTEN/NOTIFY
+type PostgresAdapter struct {
+	db       *gorm.DB
+	dsn      string
+	channel  string
+	listener *pq.Listener
+}
+
+func NewPostgresAdapter(db *gorm.DB, dsn, channel string) *PostgresAdapter {
+	return &PostgresAdapter{db: db, dsn: dsn, channel: channel}
+}
+
+func (a *PostgresAdapter) Publish(payload []byte) error {
+	message := string(payload)
+
+	if len(payload) > maxNotifyPayload {
+		stored := model.SocketBroadcast{Payload: message}
+		if err := a.db.Create(&stored).Error; err != nil {
+			return err
+		}
+
+		a.db.Delete(&model.SocketBroadcast{},
+			"created_at < ?", time.Now().Add(-storedPacketTTL),
+		) // ignore error
+
+		message = storedPrefix + stored.ID
+	}
+
+	return a.db.Exec("SELECT pg_notify(?, ?)", a.channel, message).Error
+}
+
+func (a *PostgresAdapter) Subscribe(handler func(payload []byte)) error {
+	a.listener = pq.NewListener(a.dsn, time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				slog.Error("Broadcast listener:", slog.Any("error", err))
+			}
+		},
+	)
+
+	if err := a.listener.Listen(a.channel); err != nil {
+		return err
+	}
+
+	go func() {
+		for n := range a.listener.Notify {
+			// nil is sent after the connection was re-established
+			if n == nil {
+				continue
+			}
+
+			payload, err := a.load(n.Extra)
+			if err != nil {
+				slog.Error("Broadcast listener:", slog.Any("error", err))
+				continue
+			}
+
+			handler(payload)
+		}
+	}()
+
+	return nil
+}
+
+func (a *PostgresAdapter) load(message string) ([]byte, error) {
+	id, stored := strings.CutPrefix(message, storedPrefix)
+	if !stored {
+		return []byte(message), nil
+	}
+
+	var packet model.SocketBroadcast
+	if err := a.db.First(&packet, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	return []byte(packet.Payload), nil
+}
+
+func (a *PostgresAdapter) Close() error {
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Close()
+}
@@ -8,7 +8,9 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/joho/godotenv"
 )
 
@@ -20,6 +22,29 @@ func Env(key string) string {
 	return os.Getenv(strings.ToUpper(key))
 }
 
+// EnvDuration parses the env value as duration, fallback when empty or invalid
+func EnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(Env(key))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// InstanceID identifies this server instance, defaults to the hostname
+func InstanceID() string {
+	if id := Env("INSTANCE_ID"); id != "" {
+		return id
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "default"
+	}
+
+	return hostname
+}
+
 func Ptr[T any](s T) *T {
 	return &s
 }
@@ -44,6 +69,21 @@ func BindMap[T any](data any) (T, error) {
 	return output, nil
 }
 
+// BindValid binds the socket payload like BindMap and validates the
+// binding tags the same way the http handlers do
+func BindValid[T any](data any) (T, error) {
+	output, err := BindMap[T](data)
+	if err != nil {
+		return output, err
+	}
+
+	if err := binding.Validator.ValidateStruct(&output); err != nil {
+		return output, err
+	}
+
+	return output, nil
+}
+
 func Random(length int) string {
 	const chars = "abcdefghijklmnopqrstuvwxyz"
 	result := make([]byte, length)
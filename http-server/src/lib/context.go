@@ -1,13 +1,23 @@
 package lib
 
 import (
+	"pry-teams/src/lib/broadcast"
+	"pry-teams/src/lib/schedule"
 	"pry-teams/src/services"
 
 	s "github.com/zishang520/socket.io/v2/socket"
 )
 
 type SocketContext struct {
-	Io     *s.Server
-	Socket *s.Socket
+	Io      *s.Server
+	Socket  *s.Socket
+	Timers  *schedule.Timers
+	Emitter *broadcast.Emitter
 	*services.ServiceContext
 }
+
+// Broadcast emits to the room on every instance except the current
+// socket, the cross instance equivalent of Socket.To
+func (ctx *SocketContext) Broadcast(room ...s.Room) *broadcast.Operator {
+	return ctx.Emitter.To(room...).Except(s.Room(ctx.Socket.Id()))
+}
@@ -5,12 +5,15 @@ import (
 	"log/slog"
 	c "pry-teams/src/lib/common"
 	"pry-teams/src/model"
+	"pry-teams/src/types"
 	"sync"
 	"time"
 
+	"github.com/lib/pq"
 	s "github.com/supabase-community/supabase-go"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 )
@@ -27,8 +30,23 @@ var Schema = &[]interface{}{
 	&model.PeopleWaiting{},
 	&model.RoomControl{},
 	&model.UserAccess{},
+	&model.ChatMessage{},
+	&model.PeopleBanned{},
+	&model.RoomRole{},
+	&model.SocketBroadcast{},
+	&model.RoomInvite{},
+	&model.RoomAllow{},
+	&model.DirectMessage{},
+	&model.ChatReaction{},
+	&model.RoomParticipant{},
+	&model.ServerInstance{},
+	&model.PasscodeAttempt{},
 }
 
+// people of instances without a recent heartbeat, the alive set is used
+// so rows of instances that never sent a heartbeat are included
+const staleInstance = "instance NOT IN (SELECT id FROM server_instance WHERE seen_at >= ?)"
+
 func Connect() {
 	once.Do(func() {
 		var err error
@@ -59,6 +77,10 @@ func Connect() {
 			if err != nil {
 				log.Fatalf("Migration error: %v", err)
 			}
+
+			if err := migrateHosts(); err != nil {
+				log.Fatalf("Migration error: %v", err)
+			}
 		}
 
 		supabase, err = s.NewClient(
@@ -97,16 +119,99 @@ func Disconnect() error {
 	return nil
 }
 
-func CleanUp() {
-	slog.Info("Cleaning up database...")
+// migrateHosts moves the legacy room host array into room roles,
+// the first host of the room becomes the owner
+func migrateHosts() error {
+	if !db.Migrator().HasColumn("room", "host") {
+		return nil
+	}
+
+	var rooms []struct {
+		RoomID string
+		Host   pq.StringArray
+	}
+
+	if err := db.Raw("SELECT room_id, host FROM room").Scan(&rooms).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, room := range rooms {
+			for i, userId := range room.Host {
+				role := model.RoomRole{RoomID: room.RoomID, UserID: userId, Role: types.CoHost}
+				if i == 0 {
+					role.Role = types.Owner
+				}
+
+				err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Migrator().DropColumn("room", "host")
+	})
+}
+
+// CleanUp removes the people connected to the instance, the
+// other instances sharing the database keep their people, the removed
+// people are returned to let their rooms know
+func CleanUp(instance string) []model.People {
+	slog.Info("Cleaning up database...", slog.String("instance", instance))
+
+	if err := db.Delete(&model.ServerInstance{}, "id = ?", instance).Error; err != nil {
+		log.Printf("Error deleting from server_instance: %v\n", err)
+	}
 
-	if err := db.Exec("DELETE FROM people").Error; err != nil {
+	var people []model.People
+	err := db.Clauses(clause.Returning{}).Where("instance = ?", instance).Delete(&people).Error
+	if err != nil {
 		log.Printf("Error deleting from people: %v\n", err)
 	}
 
-	if err := db.Exec("DELETE FROM people_waiting").Error; err != nil {
+	if err := db.Exec("DELETE FROM people_waiting WHERE instance = ?", instance).Error; err != nil {
 		log.Printf("Error deleting from people_waiting: %v\n", err)
 	}
 
+	// bans only last for the meeting
+	err = db.Exec("DELETE FROM people_banned WHERE room_id NOT IN (SELECT room_id FROM people)").Error
+	if err != nil {
+		log.Printf("Error deleting from people_banned: %v\n", err)
+	}
+
 	slog.Info("Database cleanup completed.")
+	return people
+}
+
+// Heartbeat marks the instance alive and removes the people and the waiting
+// people of the instances without a heartbeat since staleAfter, the removed
+// people are returned to let their rooms know
+func Heartbeat(instance string, staleAfter time.Duration) ([]model.People, error) {
+	now := time.Now()
+	alive := model.ServerInstance{ID: instance, SeenAt: now}
+
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&alive).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var people []model.People
+	stale := now.Add(-staleAfter)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Returning{}).Where(staleInstance, stale).Delete(&people).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where(staleInstance, stale).Delete(&model.PeopleWaiting{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.ServerInstance{}, "seen_at < ?", stale).Error
+	})
+
+	return people, err
 }
@@ -0,0 +1,282 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	lineLimit   = 75
+)
+
+type Organizer struct {
+	Name  string
+	Email string
+}
+
+// Event is a RFC 5545 VEVENT, times are written in UTC unless
+// a timezone is given which keeps recurrences on the local wall clock,
+// the calendar then carries the VTIMEZONE of the timezone
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Timezone    string
+	RRule       string
+	Organizer   *Organizer
+	Created     time.Time
+	Updated     time.Time
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+func (c *Calendar) String() string {
+	var b strings.Builder
+	now := time.Now()
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//pyr-teams//meeting//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+Escape(c.Name))
+	}
+
+	for _, z := range c.zones() {
+		writeTimezone(&b, z.loc, z.year)
+	}
+
+	for _, e := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+now.UTC().Format(utcLayout))
+		writeLine(&b, formatTime("DTSTART", e.Start, e.Timezone))
+		writeLine(&b, formatTime("DTEND", e.End, e.Timezone))
+		if e.RRule != "" {
+			writeLine(&b, "RRULE:"+strings.TrimPrefix(e.RRule, "RRULE:"))
+		}
+		writeLine(&b, "SUMMARY:"+Escape(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+Escape(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(&b, "LOCATION:"+Escape(e.Location))
+		}
+		if e.URL != "" {
+			writeLine(&b, "URL:"+e.URL)
+		}
+		if e.Organizer != nil && e.Organizer.Email != "" {
+			writeLine(&b, fmt.Sprintf("ORGANIZER;CN=%s:mailto:%s",
+				quote(e.Organizer.Name), e.Organizer.Email,
+			))
+		}
+		if !e.Created.IsZero() {
+			writeLine(&b, "CREATED:"+e.Created.UTC().Format(utcLayout))
+		}
+		if !e.Updated.IsZero() {
+			writeLine(&b, "LAST-MODIFIED:"+e.Updated.UTC().Format(utcLayout))
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	return b.String()
+}
+
+type zone struct {
+	loc  *time.Location
+	year int
+}
+
+// zones returns the timezones of the events with the earliest year they start
+func (c *Calendar) zones() []zone {
+	var zones []zone
+	index := map[string]int{}
+
+	for _, e := range c.Events {
+		loc := location(e.Timezone)
+		if loc == nil {
+			continue
+		}
+
+		year := e.Start.In(loc).Year()
+		if i, ok := index[loc.String()]; ok {
+			zones[i].year = min(zones[i].year, year)
+			continue
+		}
+
+		index[loc.String()] = len(zones)
+		zones = append(zones, zone{loc: loc, year: year})
+	}
+
+	return zones
+}
+
+// location returns nil for UTC and unknown timezones which are written in UTC
+func location(timezone string) *time.Location {
+	if timezone == "" || timezone == "UTC" {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+
+	return loc
+}
+
+func formatTime(name string, t time.Time, timezone string) string {
+	loc := location(timezone)
+	if loc == nil {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+
+	return fmt.Sprintf("%s;TZID=%s:%s", name, loc.String(), t.In(loc).Format(localLayout))
+}
+
+// writeTimezone writes the VTIMEZONE from the offset changes of the year before
+// the events start, a daylight saving pair repeats yearly on the same weekday
+// of the month and a timezone without changes has a single observance
+func writeTimezone(b *strings.Builder, loc *time.Location, year int) {
+	writeLine(b, "BEGIN:VTIMEZONE")
+	writeLine(b, "TZID:"+loc.String())
+
+	start := time.Date(year-1, time.January, 1, 0, 0, 0, 0, loc)
+	changes := transitions(start, start.AddDate(1, 0, 0))
+
+	if len(changes) == 0 {
+		name, offset := start.Zone()
+		writeLine(b, "BEGIN:STANDARD")
+		writeLine(b, "DTSTART:19700101T000000")
+		writeLine(b, "TZOFFSETFROM:"+formatOffset(offset))
+		writeLine(b, "TZOFFSETTO:"+formatOffset(offset))
+		writeLine(b, "TZNAME:"+name)
+		writeLine(b, "END:STANDARD")
+	}
+
+	for _, t := range changes {
+		_, from := t.Add(-time.Second).Zone()
+		name, to := t.Zone()
+		// the onset is written in the local time of the previous offset
+		onset := t.In(time.FixedZone("", from))
+
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+
+		writeLine(b, "BEGIN:"+kind)
+		writeLine(b, "DTSTART:"+onset.Format(localLayout))
+		if len(changes) == 2 {
+			writeLine(b, "RRULE:FREQ=YEARLY;"+yearlyRule(onset))
+		}
+		writeLine(b, "TZOFFSETFROM:"+formatOffset(from))
+		writeLine(b, "TZOFFSETTO:"+formatOffset(to))
+		writeLine(b, "TZNAME:"+name)
+		writeLine(b, "END:"+kind)
+	}
+
+	writeLine(b, "END:VTIMEZONE")
+}
+
+// transitions returns the instants the utc offset of the location changes
+func transitions(from, to time.Time) []time.Time {
+	var changes []time.Time
+	offset := func(unix int64) int {
+		_, offset := time.Unix(unix, 0).In(from.Location()).Zone()
+		return offset
+	}
+
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		lo, hi := day.Unix(), day.Add(24*time.Hour).Unix()
+		if offset(lo) == offset(hi) {
+			continue
+		}
+
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			if offset(mid) == offset(lo) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		changes = append(changes, time.Unix(hi, 0).In(from.Location()))
+	}
+
+	return changes
+}
+
+// yearlyRule returns the month and nth weekday of the date, the last
+// weekday of the month is written as -1
+func yearlyRule(t time.Time) string {
+	n := (t.Day()-1)/7 + 1
+	if t.Day()+7 > time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+		n = -1
+	}
+
+	weekday := strings.ToUpper(t.Weekday().String()[:2])
+
+	return fmt.Sprintf("BYMONTH=%d;BYDAY=%d%s", t.Month(), n, weekday)
+}
+
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// Escape escapes a TEXT property value
+func Escape(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+// writeLine folds content lines longer than 75 octets without
+// splitting multi-byte characters
+func writeLine(b *strings.Builder, line string) {
+	limit := lineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space
+		limit = lineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarTimes(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		event    Event
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "utc",
+			event:    Event{Start: start, End: start.Add(time.Hour)},
+			contains: []string{"DTSTART:20260302T090000Z", "DTEND:20260302T100000Z"},
+			excludes: []string{"TZID", "BEGIN:VTIMEZONE"},
+		},
+		{
+			name:     "unknown timezone",
+			event:    Event{Start: start, End: start.Add(time.Hour), Timezone: "Nowhere/Unknown"},
+			contains: []string{"DTSTART:20260302T090000Z"},
+			excludes: []string{"TZID", "BEGIN:VTIMEZONE"},
+		},
+		{
+			name: "daylight saving timezone",
+			event: Event{
+				Start: start, End: start.Add(time.Hour), Timezone: "Europe/Berlin", RRule: "FREQ=WEEKLY",
+			},
+			contains: []string{
+				"BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n",
+				"BEGIN:DAYLIGHT\r\nDTSTART:20250330T020000\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n" +
+					"TZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\n",
+				"BEGIN:STANDARD\r\nDTSTART:20251026T030000\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n" +
+					"TZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\n",
+				"DTSTART;TZID=Europe/Berlin:20260302T100000",
+				"RRULE:FREQ=WEEKLY",
+			},
+		},
+		{
+			name: "nth weekday rule",
+			event: Event{
+				Start: start, End: start.Add(time.Hour), Timezone: "America/New_York",
+			},
+			contains: []string{
+				"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+				"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+				"DTSTART;TZID=America/New_York:20260302T040000",
+			},
+		},
+		{
+			name:  "fixed offset timezone",
+			event: Event{Start: start, End: start.Add(time.Hour), Timezone: "Asia/Tokyo"},
+			contains: []string{
+				"BEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nTZOFFSETFROM:+0900\r\nTZOFFSETTO:+0900\r\n",
+				"DTSTART;TZID=Asia/Tokyo:20260302T180000",
+			},
+			excludes: []string{"BEGIN:DAYLIGHT", "RRULE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := Calendar{Events: []Event{tt.event}}
+			out := calendar.String()
+
+			for _, s := range tt.contains {
+				if !strings.Contains(out, s) {
+					t.Errorf("missing %q in\n%s", s, out)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(out, s) {
+					t.Errorf("unexpected %q in\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCalendarTimezoneOnce(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	calendar := Calendar{Events: []Event{
+		{Start: start, End: start.Add(time.Hour), Timezone: "Europe/Berlin"},
+		{Start: start.AddDate(1, 0, 0), End: start.AddDate(1, 0, 0).Add(time.Hour), Timezone: "Europe/Berlin"},
+	}}
+
+	if n := strings.Count(calendar.String(), "BEGIN:VTIMEZONE"); n != 1 {
+		t.Errorf("got %d VTIMEZONE, want 1", n)
+	}
+}
+
+func TestWriteLineFolding(t *testing.T) {
+	var b strings.Builder
+	writeLine(&b, "SUMMARY:"+strings.Repeat("é", 60))
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > lineLimit {
+			t.Errorf("line of %d octets exceeds %d", len(line), lineLimit)
+		}
+	}
+}
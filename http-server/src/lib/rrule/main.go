@@ -0,0 +1,269 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is a subset of the RFC 5545 recurrence rule supporting
+// FREQ, INTERVAL, COUNT, UNTIL and BYDAY (weekly only), the other
+// parts are rejected instead of being expanded wrongly
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    *time.Time
+	ByDay    []time.Weekday
+}
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var (
+	ErrInvalidRule     = errors.New("invalid recurrence rule")
+	ErrUnsupportedRule = errors.New("unsupported recurrence rule")
+)
+
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+
+		key = strings.ToUpper(key)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %q", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: interval %q", ErrInvalidRule, val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: count %q", ErrInvalidRule, val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, fmt.Errorf("%w: until %q", ErrInvalidRule, val)
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if _, ordinal := weekdays[strings.TrimLeft(day, "+-0123456789")]; !ok && ordinal {
+					// ordinal weekdays, eg. 1MO or -1FR
+					return nil, fmt.Errorf("%w: byday %q", ErrUnsupportedRule, day)
+				}
+				if !ok {
+					return nil, fmt.Errorf("%w: byday %q", ErrInvalidRule, day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "WKST":
+			// weeks always start on monday
+			if strings.ToUpper(val) != "MO" {
+				return nil, fmt.Errorf("%w: wkst %q", ErrUnsupportedRule, val)
+			}
+		case "BYSECOND", "BYMINUTE", "BYHOUR", "BYMONTHDAY", "BYYEARDAY", "BYWEEKNO", "BYMONTH", "BYSETPOS":
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRule, key)
+		default:
+			return nil, fmt.Errorf("%w: unknown %q", ErrInvalidRule, key)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "SECONDLY", "MINUTELY", "HOURLY":
+		return nil, fmt.Errorf("%w: freq %q", ErrUnsupportedRule, rule.Freq)
+	default:
+		return nil, fmt.Errorf("%w: freq %q", ErrInvalidRule, rule.Freq)
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, fmt.Errorf("%w: byday requires weekly freq", ErrInvalidRule)
+	}
+
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: count and until are exclusive", ErrInvalidRule)
+	}
+
+	slices.SortFunc(rule.ByDay, func(a, b time.Weekday) int {
+		return weekdayIndex(a) - weekdayIndex(b)
+	})
+	rule.ByDay = slices.Compact(rule.ByDay)
+
+	return &rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidRule
+}
+
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences of the rule starting at dtstart that begin
+// within [from, to), the wall clock of dtstart is kept in its location
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+
+	r.each(dtstart, to, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return true
+	})
+
+	return result
+}
+
+// each calls fn for every occurrence in order until fn returns false, the
+// rule is exhausted or the periods start at end, open ended rules stop there
+func (r *Rule) each(dtstart, end time.Time, fn func(time.Time) bool) {
+	count := 0
+
+	emit := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		if r.Count > 0 && count >= r.Count {
+			return false
+		}
+		count++
+		return fn(t)
+	}
+
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+
+	for i := 0; ; i++ {
+		n := i * r.Interval
+
+		// the first day of the period, no occurrence of the period is earlier
+		var period time.Time
+		switch r.Freq {
+		case Daily:
+			period = time.Date(y, m, d+n, 0, 0, 0, 0, loc)
+		case Weekly:
+			period = time.Date(y, m, d+7*n-weekdayIndex(dtstart.Weekday()), 0, 0, 0, 0, loc)
+		case Monthly:
+			period = time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, loc)
+		case Yearly:
+			period = time.Date(y+n, time.January, 1, 0, 0, 0, 0, loc)
+		default:
+			return
+		}
+
+		if !period.Before(end) {
+			return
+		}
+
+		switch r.Freq {
+		case Daily:
+			if !emit(time.Date(y, m, d+n, hh, mm, ss, 0, loc)) {
+				return
+			}
+		case Weekly:
+			if len(r.ByDay) == 0 {
+				if !emit(time.Date(y, m, d+7*n, hh, mm, ss, 0, loc)) {
+					return
+				}
+				continue
+			}
+
+			// monday of the week containing dtstart
+			monday := d - weekdayIndex(dtstart.Weekday())
+			for _, day := range r.ByDay {
+				t := time.Date(y, m, monday+7*n+weekdayIndex(day), hh, mm, ss, 0, loc)
+				if !emit(t) {
+					return
+				}
+			}
+		case Monthly:
+			t := time.Date(y, m+time.Month(n), d, hh, mm, ss, 0, loc)
+			// skip months without the day, e.g. the 31st
+			if t.Day() == d && !emit(t) {
+				return
+			}
+		case Yearly:
+			t := time.Date(y+n, m, d, hh, mm, ss, 0, loc)
+			// skip years without the day, e.g. february 29th
+			if t.Day() == d && !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// weekdayIndex returns the day of the week starting from monday
+func weekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
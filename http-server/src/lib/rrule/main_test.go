@@ -0,0 +1,200 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		rule  string
+		err   error
+	}{
+		{value: "FREQ=DAILY", rule: "FREQ=DAILY"},
+		{value: "RRULE:freq=weekly;byday=fr,mo,mo;interval=2", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{value: "FREQ=MONTHLY;COUNT=3;WKST=MO", rule: "FREQ=MONTHLY;COUNT=3"},
+		{value: "FREQ=YEARLY;UNTIL=20300101", rule: "FREQ=YEARLY;UNTIL=20300101T000000Z"},
+
+		{value: "", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;", err: ErrInvalidRule},
+		{value: "FREQ=FORTNIGHTLY", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;FREQ=WEEKLY", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;INTERVAL=0", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;COUNT=-1", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;UNTIL=tomorrow", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;COUNT=2;UNTIL=20300101", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;BYDAY=MO", err: ErrInvalidRule},
+		{value: "FREQ=WEEKLY;BYDAY=XX", err: ErrInvalidRule},
+		{value: "FREQ=DAILY;X-NAME=1", err: ErrInvalidRule},
+
+		{value: "FREQ=HOURLY", err: ErrUnsupportedRule},
+		{value: "FREQ=MONTHLY;BYMONTHDAY=15", err: ErrUnsupportedRule},
+		{value: "FREQ=MONTHLY;BYDAY=MO;BYSETPOS=-1", err: ErrUnsupportedRule},
+		{value: "FREQ=YEARLY;BYMONTH=3", err: ErrUnsupportedRule},
+		{value: "FREQ=YEARLY;BYWEEKNO=20", err: ErrUnsupportedRule},
+		{value: "FREQ=YEARLY;BYYEARDAY=100", err: ErrUnsupportedRule},
+		{value: "FREQ=DAILY;BYHOUR=9", err: ErrUnsupportedRule},
+		{value: "FREQ=MONTHLY;BYDAY=1MO", err: ErrUnsupportedRule},
+		{value: "FREQ=WEEKLY;BYDAY=-1FR", err: ErrUnsupportedRule},
+		{value: "FREQ=WEEKLY;WKST=SU", err: ErrUnsupportedRule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rule, err := Parse(tt.value)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if rule.String() != tt.rule {
+				t.Errorf("got %q, want %q", rule.String(), tt.rule)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	// monday 2 march 2026, 9:00 utc
+	dtstart := date(2026, time.March, 2, 9)
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		from    time.Time
+		to      time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: dtstart,
+			from:    dtstart,
+			to:      dtstart.AddDate(1, 0, 0),
+			want:    []time.Time{dtstart, dtstart.AddDate(0, 0, 1), dtstart.AddDate(0, 0, 2)},
+		},
+		{
+			name:    "count before the window",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: dtstart,
+			from:    dtstart.AddDate(0, 0, 2),
+			to:      dtstart.AddDate(1, 0, 0),
+			want:    []time.Time{dtstart.AddDate(0, 0, 2)},
+		},
+		{
+			name:    "until inclusive",
+			rule:    "FREQ=WEEKLY;UNTIL=20260316T090000Z",
+			dtstart: dtstart,
+			from:    dtstart,
+			to:      dtstart.AddDate(1, 0, 0),
+			want:    []time.Time{dtstart, dtstart.AddDate(0, 0, 7), dtstart.AddDate(0, 0, 14)},
+		},
+		{
+			name:    "daily interval",
+			rule:    "FREQ=DAILY;INTERVAL=3",
+			dtstart: dtstart,
+			from:    dtstart,
+			to:      dtstart.AddDate(0, 0, 9),
+			want:    []time.Time{dtstart, dtstart.AddDate(0, 0, 3), dtstart.AddDate(0, 0, 6)},
+		},
+		{
+			name:    "weekly interval by day",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			dtstart: dtstart,
+			from:    dtstart,
+			to:      dtstart.AddDate(0, 0, 21),
+			want: []time.Time{
+				dtstart, dtstart.AddDate(0, 0, 2),
+				dtstart.AddDate(0, 0, 14), dtstart.AddDate(0, 0, 16),
+			},
+		},
+		{
+			name:    "by day before dtstart",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3",
+			dtstart: dtstart.AddDate(0, 0, 2),
+			from:    dtstart,
+			to:      dtstart.AddDate(0, 1, 0),
+			want:    []time.Time{dtstart.AddDate(0, 0, 4), dtstart.AddDate(0, 0, 7), dtstart.AddDate(0, 0, 11)},
+		},
+		{
+			name:    "monthly skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: date(2026, time.January, 31, 9),
+			from:    date(2026, time.January, 1, 0),
+			to:      date(2027, time.January, 1, 0),
+			want:    []time.Time{date(2026, time.January, 31, 9), date(2026, time.March, 31, 9), date(2026, time.May, 31, 9)},
+		},
+		{
+			name:    "yearly leap day",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: date(2024, time.February, 29, 9),
+			from:    date(2024, time.January, 1, 0),
+			to:      date(2040, time.January, 1, 0),
+			want:    []time.Time{date(2024, time.February, 29, 9), date(2028, time.February, 29, 9)},
+		},
+		{
+			name:    "wall clock across daylight saving",
+			rule:    "FREQ=WEEKLY",
+			dtstart: time.Date(2026, time.March, 23, 9, 0, 0, 0, berlin),
+			from:    date(2026, time.March, 1, 0),
+			to:      date(2026, time.April, 1, 0),
+			want: []time.Time{
+				time.Date(2026, time.March, 23, 9, 0, 0, 0, berlin),
+				time.Date(2026, time.March, 30, 9, 0, 0, 0, berlin),
+			},
+		},
+		{
+			name:    "open ended far in the future",
+			rule:    "FREQ=DAILY",
+			dtstart: dtstart,
+			from:    date(2076, time.March, 2, 0),
+			to:      date(2076, time.March, 4, 0),
+			want:    []time.Time{date(2076, time.March, 2, 9), date(2076, time.March, 3, 9)},
+		},
+		{
+			name:    "window before dtstart",
+			rule:    "FREQ=DAILY",
+			dtstart: dtstart,
+			from:    dtstart.AddDate(0, 0, -7),
+			to:      dtstart,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := rule.Between(tt.dtstart, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+)
+
+// Timers is a registry of named timers shared between socket connections
+type Timers struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func New() *Timers {
+	return &Timers{timers: make(map[string]*time.Timer)}
+}
+
+// Start runs fn after the duration, an existing timer with the same key is replaced
+func (t *Timers) Start(key string, d time.Duration, fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if timer, ok := t.timers[key]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		t.mu.Lock()
+		if t.timers[key] == timer {
+			delete(t.timers, key)
+		}
+		t.mu.Unlock()
+		fn()
+	})
+	t.timers[key] = timer
+}
+
+// Stop cancels the timer, returns false when there is no pending timer
+func (t *Timers) Stop(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, ok := t.timers[key]
+	if !ok {
+		return false
+	}
+
+	delete(t.timers, key)
+	return timer.Stop()
+}
+
+func (t *Timers) Has(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.timers[key]
+	return ok
+}
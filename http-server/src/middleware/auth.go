@@ -2,13 +2,14 @@ package middleware
 
 import (
 	"net/http"
-	"pry-teams/src/lib/database"
+	"pry-teams/src/lib/auth"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SupbaseAuth() gin.HandlerFunc {
+// Auth authenticates the bearer token with the configured auth provider
+func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,10 +28,7 @@ func SupbaseAuth() gin.HandlerFunc {
 		}
 		token := tokenParts[1]
 
-		supabase := database.Supabase()
-		supabaseAuth := supabase.Auth.WithToken(token)
-
-		user, err := supabaseAuth.GetUser()
+		user, err := auth.Authenticate(token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Unauthorized",
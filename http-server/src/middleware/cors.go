@@ -33,6 +33,6 @@ func Cors() gin.HandlerFunc {
 		"Access-Control-Allow-Credentials",
 	}
 	config.AllowCredentials = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	return cors.New(config)
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"pry-teams/src/types"
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// ChatMessage of the room, deleted messages stay in the history without
+// their text. Replies belong to the thread of the parent message and are
+// kept out of the main stream, the parent counts them. The text is indexed
+// for full text search by a generated column
+type ChatMessage struct {
+	ID          string                `gorm:"primaryKey;size:25" json:"id"`
+	RoomID      string                `gorm:"index;column:room_id" json:"roomId"`
+	UserID      string                `gorm:"column:user_id" json:"userId"`
+	Name        string                `json:"name"`
+	Text        string                `json:"text"`
+	Timestamp   float64               `json:"timestamp"`
+	EditedAt    *time.Time            `gorm:"column:edited_at" json:"editedAt"`
+	DeletedAt   *time.Time            `gorm:"column:deleted_at" json:"deletedAt"`
+	DeletedBy   *string               `gorm:"column:deleted_by" json:"deletedBy"`
+	ParentID    *string               `gorm:"index;size:25;column:parent_id" json:"parentId"`
+	ReplyCount  int                   `gorm:"default:0;column:reply_count" json:"replyCount"`
+	LastReplyAt *time.Time            `gorm:"column:last_reply_at" json:"lastReplyAt"`
+	Replies     []ChatMessage         `gorm:"-" json:"replies,omitempty"`
+	Search      string                `gorm:"type:tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce(text, ''))) STORED;index:idx_chat_message_search,type:gin;->:false;<-:false" json:"-"`
+	Reactions   []types.ReactionCount `gorm:"-" json:"reactions"`
+	Room        Room                  `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt   time.Time             `gorm:"index;column:created_at;<-:create" json:"createdAt"`
+}
+
+func (ChatMessage) TableName() string {
+	return "chat_message"
+}
+
+func (m *ChatMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// ChatReaction is the emoji reaction of a user to a chat message, a user
+// reacts once per emoji
+type ChatReaction struct {
+	ID        string      `gorm:"primaryKey;size:25" json:"id"`
+	MessageID string      `gorm:"uniqueIndex:idx_chat_reaction_message_user_emoji;column:message_id" json:"messageId"`
+	UserID    string      `gorm:"uniqueIndex:idx_chat_reaction_message_user_emoji;column:user_id" json:"userId"`
+	Emoji     string      `gorm:"uniqueIndex:idx_chat_reaction_message_user_emoji;size:32" json:"emoji"`
+	Message   ChatMessage `gorm:"foreignKey:MessageID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time   `gorm:"column:created_at;<-:create" json:"createdAt"`
+}
+
+func (ChatReaction) TableName() string {
+	return "chat_reaction"
+}
+
+func (r *ChatReaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// DirectMessage is a private message between two participants of the room,
+// kept apart from the public chat history
+type DirectMessage struct {
+	ID              string    `gorm:"primaryKey;size:25" json:"id"`
+	RoomID          string    `gorm:"index:idx_direct_message_room_sender;index:idx_direct_message_room_recipient;column:room_id" json:"roomId"`
+	SenderID        string    `gorm:"index:idx_direct_message_room_sender;column:sender_id" json:"senderId"`
+	SenderPeerID    string    `gorm:"column:sender_peer_id" json:"senderPeerId"`
+	Name            string    `json:"name"`
+	RecipientID     string    `gorm:"index:idx_direct_message_room_recipient;column:recipient_id" json:"recipientId"`
+	RecipientPeerID string    `gorm:"column:recipient_peer_id" json:"recipientPeerId"`
+	Text            string    `json:"text"`
+	Timestamp       float64   `json:"timestamp"`
+	Room            Room      `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt       time.Time `gorm:"index;column:created_at;<-:create" json:"createdAt"`
+}
+
+func (DirectMessage) TableName() string {
+	return "direct_message"
+}
+
+func (m *DirectMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = cuid.New()
+	}
+	return nil
+}
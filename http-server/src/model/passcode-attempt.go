@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// PasscodeAttempt counts the wrong passcodes of a key until the window is
+// over, the count is shared by every instance using the database
+type PasscodeAttempt struct {
+	Key       string    `gorm:"primaryKey;size:255;column:key" json:"key"`
+	Failures  int       `gorm:"default:0" json:"failures"`
+	ExpiresAt time.Time `gorm:"index;column:expires_at" json:"expiresAt"`
+}
+
+func (PasscodeAttempt) TableName() string {
+	return "passcode_attempt"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// PeopleBanned keeps users removed by a host out of the room
+// until the meeting is over
+type PeopleBanned struct {
+	ID        string    `gorm:"primaryKey;size:25" json:"id"`
+	RoomID    string    `gorm:"uniqueIndex:idx_people_banned_room_user;column:room_id" json:"roomId"`
+	UserID    string    `gorm:"uniqueIndex:idx_people_banned_room_user;column:user_id" json:"userId"`
+	Room      Room      `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;<-:create" json:"createdAt"`
+}
+
+func (PeopleBanned) TableName() string {
+	return "people_banned"
+}
+
+func (u *PeopleBanned) BeforeCreate(tx *gorm.DB) (err error) {
+	if u.ID == "" {
+		u.ID = cuid.New()
+	}
+	return nil
+}
@@ -8,17 +8,22 @@ import (
 )
 
 type People struct {
-	ID        string    `gorm:"primaryKey;size:25" json:"id"`
-	RoomID    string    `gorm:"column:room_id" json:"roomId"`
-	PeerID    string    `gorm:"unique;column:peer_id" json:"peerId"`
-	SocketID  string    `gorm:"column:socket_id" json:"socketId"`
-	UserID    string    `gorm:"column:user_id" json:"userId"`
-	Name      string    `json:"name"`
-	Photo     *string   `json:"photo,omitempty"`
-	Muted     bool      `gorm:"default:false" json:"muted"`
-	Visible   bool      `gorm:"default:false" json:"visible"`
-	Room      Room      `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"room"`
-	CreatedAt time.Time `gorm:"column:created_at;<-:create" json:"createdAt"`
+	ID           string    `gorm:"primaryKey;size:25" json:"id"`
+	RoomID       string    `gorm:"column:room_id" json:"roomId"`
+	PeerID       string    `gorm:"unique;column:peer_id" json:"peerId"`
+	SocketID     string    `gorm:"column:socket_id" json:"socketId"`
+	UserID       string    `gorm:"column:user_id" json:"userId"`
+	Name         string    `json:"name"`
+	Photo        *string   `json:"photo,omitempty"`
+	Muted        bool      `gorm:"default:false" json:"muted"`
+	Visible      bool      `gorm:"default:false" json:"visible"`
+	Guest        bool      `gorm:"default:false" json:"guest"`
+	Reconnecting bool      `gorm:"default:false" json:"reconnecting"`
+	Companion    bool      `gorm:"default:false" json:"companion"`
+	Preapproved  bool      `gorm:"default:false" json:"-"`
+	Instance     string    `gorm:"index;column:instance" json:"-"`
+	Room         Room      `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"room"`
+	CreatedAt    time.Time `gorm:"column:created_at;<-:create" json:"createdAt"`
 }
 
 func (People) TableName() string {
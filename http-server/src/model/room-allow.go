@@ -0,0 +1,31 @@
+package model
+
+import (
+	"pry-teams/src/types"
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// RoomAllow is an allowlist entry of the room, an email, a domain or an
+// organization depending on the access type it belongs to
+type RoomAllow struct {
+	ID        string       `gorm:"primaryKey;size:25" json:"id"`
+	RoomID    string       `gorm:"uniqueIndex:idx_room_allow_room_access_value;column:room_id" json:"roomId"`
+	Access    types.Access `gorm:"uniqueIndex:idx_room_allow_room_access_value;size:16" json:"access"`
+	Value     string       `gorm:"uniqueIndex:idx_room_allow_room_access_value;size:255" json:"value"`
+	Room      Room         `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time    `gorm:"column:created_at;<-:create" json:"createdAt"`
+}
+
+func (RoomAllow) TableName() string {
+	return "room_allow"
+}
+
+func (a *RoomAllow) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = cuid.New()
+	}
+	return nil
+}
@@ -9,19 +9,26 @@ import (
 )
 
 type RoomControl struct {
-	ID               string        `gorm:"primaryKey;size:25" json:"id"`
-	RoomID           string        `gorm:"unique;column:room_id" json:"roomId"`
-	HostManagement   *bool         `gorm:"default:false" json:"hostManagement"`
-	AllowShareScreen *bool         `gorm:"default:true" json:"allowShareScreen"`
-	AllowSendChat    *bool         `gorm:"default:true" json:"allowSendChat"`
-	AllowReaction    *bool         `gorm:"default:true" json:"allowReaction"`
-	AllowMicrophone  *bool         `gorm:"default:true" json:"allowMicrophone"`
-	AllowVideo       *bool         `gorm:"default:true" json:"allowVideo"`
-	RequireHost      *bool         `gorm:"default:false" json:"requireHost"`
-	AccessType       *types.Access `gorm:"default:trusted" json:"access"`
-	Room             Room          `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"room"`
-	CreatedAt        time.Time     `gorm:"column:created_at;<-:create" json:"createdAt"`
-	UpdatedAt        time.Time     `gorm:"column:updated_at;" json:"updatedAt"`
+	ID               string              `gorm:"primaryKey;size:25" json:"id"`
+	RoomID           string              `gorm:"unique;column:room_id" json:"roomId"`
+	HostManagement   *bool               `gorm:"default:false" json:"hostManagement"`
+	AllowShareScreen *bool               `gorm:"default:true" json:"allowShareScreen"`
+	AllowSendChat    *bool               `gorm:"default:true" json:"allowSendChat"`
+	AllowReaction    *bool               `gorm:"default:true" json:"allowReaction"`
+	AllowMicrophone  *bool               `gorm:"default:true" json:"allowMicrophone"`
+	AllowVideo       *bool               `gorm:"default:true" json:"allowVideo"`
+	RequireHost      *bool               `gorm:"default:false" json:"requireHost"`
+	AccessType       *types.Access       `gorm:"default:trusted" json:"access"`
+	MaxParticipants  *int                `gorm:"default:0;column:max_participants" json:"maxParticipants"`
+	Locked           *bool               `gorm:"default:false" json:"locked"`
+	DirectMessages   *types.DirectPolicy `gorm:"size:16;default:everyone;column:direct_messages" json:"directMessages"`
+	Passcode         *string             `gorm:"column:passcode" json:"-"`
+	HostDeadline     *time.Time          `gorm:"column:host_deadline" json:"-"`
+	Protected        bool                `gorm:"-" json:"protected"`
+	Allowlist        *types.Allowlist    `gorm:"-" json:"allowlist,omitempty"`
+	Room             Room                `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"room"`
+	CreatedAt        time.Time           `gorm:"column:created_at;<-:create" json:"createdAt"`
+	UpdatedAt        time.Time           `gorm:"column:updated_at;" json:"updatedAt"`
 }
 
 func (RoomControl) TableName() string {
@@ -34,3 +41,79 @@ func (r *RoomControl) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+func (r *RoomControl) AfterFind(tx *gorm.DB) (err error) {
+	r.Protected = r.Passcode != nil
+	return nil
+}
+
+// Limit returns the maximum number of participants, zero is unlimited
+func (r *RoomControl) Limit() int {
+	if r.MaxParticipants == nil {
+		return 0
+	}
+	return *r.MaxParticipants
+}
+
+// Control returns the control sent to the room, without the passcode
+// and the allowlist
+func (r *RoomControl) Control() types.Control {
+	value := func(v *bool, fallback bool) bool {
+		if v == nil {
+			return fallback
+		}
+		return *v
+	}
+
+	control := types.Control{
+		HostManagement:   value(r.HostManagement, false),
+		AllowShareScreen: r.Allow(types.AllowShareScreen),
+		AllowSendChat:    r.Allow(types.AllowSendChat),
+		AllowReaction:    r.Allow(types.AllowReaction),
+		AllowMicrophone:  r.Allow(types.AllowMicrophone),
+		AllowVideo:       r.Allow(types.AllowVideo),
+		RequireHost:      value(r.RequireHost, false),
+		AccessType:       types.Trusted,
+		Protected:        r.Protected,
+		Locked:           value(r.Locked, false),
+		DirectMessages:   r.DirectPolicy(),
+	}
+
+	if r.AccessType != nil {
+		control.AccessType = *r.AccessType
+	}
+
+	limit := r.Limit()
+	control.MaxParticipants = &limit
+
+	return control
+}
+
+// DirectPolicy returns who is able to send direct messages, everyone by default
+func (r *RoomControl) DirectPolicy() types.DirectPolicy {
+	if r.DirectMessages == nil || *r.DirectMessages == "" {
+		return types.DirectEveryone
+	}
+	return *r.DirectMessages
+}
+
+// Allow reports whether participants are permitted to perform the action,
+// unset values fall back to the column default (allowed)
+func (r *RoomControl) Allow(permission types.Permission) bool {
+	var allow *bool
+
+	switch permission {
+	case types.AllowShareScreen:
+		allow = r.AllowShareScreen
+	case types.AllowSendChat:
+		allow = r.AllowSendChat
+	case types.AllowReaction:
+		allow = r.AllowReaction
+	case types.AllowMicrophone:
+		allow = r.AllowMicrophone
+	case types.AllowVideo:
+		allow = r.AllowVideo
+	}
+
+	return allow == nil || *allow
+}
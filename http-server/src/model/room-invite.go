@@ -0,0 +1,63 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// RoomInvite lets the holder of the signed token skip the lobby,
+// optionally limited by expiry, number of uses or emails
+type RoomInvite struct {
+	ID        string         `gorm:"primaryKey;size:25" json:"id"`
+	RoomID    string         `gorm:"index;column:room_id" json:"roomId"`
+	CreatedBy string         `gorm:"column:created_by" json:"createdBy"`
+	Emails    pq.StringArray `gorm:"type:text[];column:emails" json:"emails"`
+	MaxUses   *int           `gorm:"column:max_uses" json:"maxUses"`
+	Uses      int            `gorm:"default:0;column:uses" json:"uses"`
+	ExpiresAt *time.Time     `gorm:"column:expires_at" json:"expiresAt"`
+	RevokedAt *time.Time     `gorm:"column:revoked_at" json:"revokedAt"`
+	Token     string         `gorm:"-" json:"token,omitempty"`
+	URL       string         `gorm:"-" json:"url,omitempty"`
+	Room      Room           `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time      `gorm:"column:created_at;<-:create" json:"createdAt"`
+}
+
+func (RoomInvite) TableName() string {
+	return "room_invite"
+}
+
+func (i *RoomInvite) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = cuid.New()
+	}
+	return nil
+}
+
+// Valid reports whether the invite can still be used by the email,
+// the email must be verified by the caller
+func (i *RoomInvite) Valid(now time.Time, email string) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+
+	if i.MaxUses != nil && i.Uses >= *i.MaxUses {
+		return false
+	}
+
+	if len(i.Emails) == 0 {
+		return true
+	}
+
+	return email != "" && slices.ContainsFunc(i.Emails, func(e string) bool {
+		return strings.EqualFold(e, email)
+	})
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// RoomParticipant remembers the users who took part in the room, kept
+// after they left so past meetings stay searchable for them
+type RoomParticipant struct {
+	ID           string    `gorm:"primaryKey;size:25" json:"id"`
+	RoomID       string    `gorm:"uniqueIndex:idx_room_participant_room_user;column:room_id" json:"roomId"`
+	UserID       string    `gorm:"uniqueIndex:idx_room_participant_room_user;index;column:user_id" json:"userId"`
+	LastJoinedAt time.Time `gorm:"column:last_joined_at" json:"lastJoinedAt"`
+	Room         Room      `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt    time.Time `gorm:"column:created_at;<-:create" json:"createdAt"`
+}
+
+func (RoomParticipant) TableName() string {
+	return "room_participant"
+}
+
+func (p *RoomParticipant) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"pry-teams/src/types"
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// RoomRole is the role of a user in the room, users without
+// a role are attendees
+type RoomRole struct {
+	ID        string     `gorm:"primaryKey;size:25" json:"id"`
+	RoomID    string     `gorm:"uniqueIndex:idx_room_role_room_user;column:room_id" json:"roomId"`
+	UserID    string     `gorm:"uniqueIndex:idx_room_role_room_user;index;column:user_id" json:"userId"`
+	Role      types.Role `gorm:"size:16;default:attendee" json:"role"`
+	Room      Room       `gorm:"foreignKey:RoomID;references:RoomId;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time  `gorm:"column:created_at;<-:create" json:"createdAt"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;" json:"updatedAt"`
+}
+
+func (RoomRole) TableName() string {
+	return "room_role"
+}
+
+func (r *RoomRole) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = cuid.New()
+	}
+	return nil
+}
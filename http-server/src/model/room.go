@@ -1,19 +1,25 @@
 package model
 
 import (
+	"pry-teams/src/lib/rrule"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/lucsky/cuid"
 	"gorm.io/gorm"
 )
 
 type Room struct {
-	ID        string         `gorm:"primaryKey;size:25" json:"id"`
-	RoomId    string         `gorm:"unique;column:room_id" json:"roomId"`
-	Host      pq.StringArray `gorm:"type:text[];" json:"-"`
-	CreatedAt time.Time      `gorm:"column:created_at;<-:create" json:"createdAt"`
-	UpdatedAt time.Time      `gorm:"column:updated_at;" json:"updatedAt"`
+	ID          string     `gorm:"primaryKey;size:25" json:"id"`
+	RoomId      string     `gorm:"unique;column:room_id" json:"roomId"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	StartAt     *time.Time `gorm:"column:start_at" json:"startAt,omitempty"`
+	EndAt       *time.Time `gorm:"column:end_at" json:"endAt,omitempty"`
+	Timezone    *string    `json:"timezone,omitempty"`
+	Recurrence  *string    `json:"recurrence,omitempty"`
+	ArchivedAt  *time.Time `gorm:"column:archived_at" json:"archivedAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at;<-:create" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;" json:"updatedAt"`
 
 	RoomControl   *RoomControl    `gorm:"foreignKey:RoomID;references:RoomId" json:"control,omitempty"`
 	Peoples       []People        `gorm:"foreignKey:RoomID;references:RoomId" json:"peoples,omitempty"`
@@ -30,3 +36,61 @@ func (u *Room) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// Scheduled reports whether the room is a scheduled meeting,
+// ad-hoc rooms can be joined at any time
+func (u *Room) Scheduled() bool {
+	return u.StartAt != nil && u.EndAt != nil
+}
+
+func (u *Room) Location() *time.Location {
+	if u.Timezone == nil {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(*u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Occurrences returns the start time of every meeting overlapping [from, to)
+func (u *Room) Occurrences(from, to time.Time) ([]time.Time, error) {
+	if !u.Scheduled() {
+		return nil, nil
+	}
+
+	start := u.StartAt.In(u.Location())
+	duration := u.EndAt.Sub(*u.StartAt)
+
+	if u.Recurrence == nil || *u.Recurrence == "" {
+		if start.Before(to) && start.Add(duration).After(from) {
+			return []time.Time{start}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := rrule.Parse(*u.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule.Between(start, from.Add(-duration+1), to), nil
+}
+
+// Joinable reports whether a meeting is running at the time, or about
+// to start within the early join window
+func (u *Room) Joinable(now time.Time, early time.Duration) bool {
+	if !u.Scheduled() {
+		return true
+	}
+
+	occurrences, err := u.Occurrences(now, now.Add(early+1))
+	if err != nil {
+		return false
+	}
+
+	return len(occurrences) > 0
+}
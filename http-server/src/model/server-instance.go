@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// ServerInstance is the heartbeat of a server instance sharing the database
+type ServerInstance struct {
+	ID     string    `gorm:"primaryKey;size:255" json:"id"`
+	SeenAt time.Time `gorm:"index;column:seen_at" json:"seenAt"`
+}
+
+func (ServerInstance) TableName() string {
+	return "server_instance"
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// SocketBroadcast keeps the packets too large for a postgres notification
+type SocketBroadcast struct {
+	ID        string    `gorm:"primaryKey;size:25" json:"id"`
+	Payload   string    `gorm:"type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"index;column:created_at;<-:create" json:"createdAt"`
+}
+
+func (SocketBroadcast) TableName() string {
+	return "socket_broadcast"
+}
+
+func (b *SocketBroadcast) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = cuid.New()
+	}
+	return nil
+}
@@ -8,13 +8,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// UserAccess keeps the settings of the user for their new rooms, the name
+// and the email are the profile of the user when they last created a room
+// and name the owner in the calendar invites
 type UserAccess struct {
-	ID          string        `gorm:"primaryKey;size:25" json:"id"`
-	UserID      string        `gorm:"unique;column:user_id" json:"userId"`
-	RequireHost *bool         `gorm:"default:false" json:"requireHost"`
-	AccessType  *types.Access `gorm:"default:trusted" json:"access"`
-	CreatedAt   time.Time     `gorm:"column:created_at;<-:create" json:"createdAt"`
-	UpdatedAt   time.Time     `gorm:"column:updated_at;" json:"updatedAt"`
+	ID            string        `gorm:"primaryKey;size:25" json:"id"`
+	UserID        string        `gorm:"unique;column:user_id" json:"userId"`
+	RequireHost   *bool         `gorm:"default:false" json:"requireHost"`
+	AccessType    *types.Access `gorm:"default:trusted" json:"access"`
+	CalendarToken *string       `gorm:"unique;column:calendar_token" json:"-"`
+	Name          string        `json:"-"`
+	Email         string        `json:"-"`
+	CreatedAt     time.Time     `gorm:"column:created_at;<-:create" json:"createdAt"`
+	UpdatedAt     time.Time     `gorm:"column:updated_at;" json:"updatedAt"`
 }
 
 func (UserAccess) TableName() string {
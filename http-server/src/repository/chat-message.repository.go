@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"pry-teams/src/model"
+	"pry-teams/src/types"
+
+	"gorm.io/gorm"
+)
+
+type ChatMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewChatMessageRepository(db *gorm.DB) *ChatMessageRepository {
+	return &ChatMessageRepository{db: db}
+}
+
+func (r *ChatMessageRepository) FindOne(conds ...interface{}) (*model.ChatMessage, error) {
+	var message model.ChatMessage
+
+	err := r.db.First(&message, conds...).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// FindLatest returns a page of messages ordered from newest to oldest
+func (r *ChatMessageRepository) FindLatest(limit, offset int, query interface{}, args ...interface{}) ([]model.ChatMessage, error) {
+	var messages []model.ChatMessage
+
+	err := r.db.Where(query, args...).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *ChatMessageRepository) Save(data *model.ChatMessage) error {
+	return r.db.Save(&data).Error
+}
+
+// Update saves the columns of the message only, leaving the reply counters
+// maintained by SaveReply untouched
+func (r *ChatMessageRepository) Update(data *model.ChatMessage, columns ...string) error {
+	return r.db.Model(data).Select(columns).Updates(data).Error
+}
+
+// SaveReply creates the reply and counts it on the parent message
+func (r *ChatMessageRepository) SaveReply(data *model.ChatMessage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(data).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.ChatMessage{}).
+			Where("id = ?", *data.ParentID).
+			Updates(map[string]interface{}{
+				"reply_count":   gorm.Expr("reply_count + 1"),
+				"last_reply_at": data.CreatedAt,
+			}).Error
+	})
+}
+
+// Search returns a page of the messages of the room matching the web search
+// query, best matches first, the snippets wrap the matches in start and stop
+func (r *ChatMessageRepository) Search(roomId, query, start, stop string, limit, offset int) ([]types.ChatSearchResult, int64, error) {
+	matches := r.db.Table("chat_message, websearch_to_tsquery('simple', ?) AS query", query).
+		Where("room_id = ? AND deleted_at IS NULL AND search @@ query", roomId)
+
+	var total int64
+	if err := matches.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []types.ChatSearchResult
+	err := matches.Session(&gorm.Session{}).
+		Select(
+			"id, user_id, name, parent_id, timestamp, created_at, "+
+				"ts_headline('simple', text, query, ?) AS snippet, ts_rank(search, query) AS rank",
+			fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=20, MinWords=5`, start, stop),
+		).
+		Order("rank DESC, created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&results).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+func (r *ChatMessageRepository) Count(query interface{}, args ...interface{}) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ChatMessage{}).Where(query, args...).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+	"pry-teams/src/types"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChatReactionRepository struct {
+	db *gorm.DB
+}
+
+func NewChatReactionRepository(db *gorm.DB) *ChatReactionRepository {
+	return &ChatReactionRepository{db: db}
+}
+
+// Toggle adds the reaction of the user or removes it when already given
+func (r *ChatReactionRepository) Toggle(messageId, userId, emoji string) error {
+	result := r.db.Delete(
+		&model.ChatReaction{}, "message_id = ? AND user_id = ? AND emoji = ?", messageId, userId, emoji,
+	)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	reaction := model.ChatReaction{MessageID: messageId, UserID: userId, Emoji: emoji}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error
+}
+
+func (r *ChatReactionRepository) Delete(conds ...interface{}) error {
+	var reaction model.ChatReaction
+	return r.db.Delete(&reaction, conds...).Error
+}
+
+// Aggregate returns the reactions of the messages counted per emoji,
+// keyed by message id
+func (r *ChatReactionRepository) Aggregate(query interface{}, args ...interface{}) (map[string][]types.ReactionCount, error) {
+	var rows []struct {
+		MessageID string
+		Emoji     string
+		Count     int64
+		Users     pq.StringArray `gorm:"type:text[]"`
+	}
+
+	err := r.db.Model(&model.ChatReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, array_agg(user_id ORDER BY created_at) AS users").
+		Where(query, args...).
+		Group("message_id, emoji").
+		Order("MIN(created_at)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make(map[string][]types.ReactionCount)
+	for _, row := range rows {
+		reactions[row.MessageID] = append(reactions[row.MessageID], types.ReactionCount{
+			Emoji: row.Emoji,
+			Count: row.Count,
+			Users: row.Users,
+		})
+	}
+
+	return reactions, nil
+}
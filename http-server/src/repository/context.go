@@ -9,6 +9,15 @@ type RepoContext struct {
 	PeopleWaiting *PeopleWaitingRepository
 	RoomControl   *RoomControlRepository
 	UserAccess    *UserAccessRepository
+	ChatMessage   *ChatMessageRepository
+	PeopleBanned  *PeopleBannedRepository
+	RoomRole      *RoomRoleRepository
+	RoomInvite    *RoomInviteRepository
+	RoomAllow     *RoomAllowRepository
+	DirectMessage *DirectMessageRepository
+	ChatReaction  *ChatReactionRepository
+	Participant   *RoomParticipantRepository
+	Attempt       *PasscodeAttemptRepository
 }
 
 func NewContext(db *gorm.DB) *RepoContext {
@@ -19,5 +28,14 @@ func NewContext(db *gorm.DB) *RepoContext {
 		PeopleWaiting: NewPeopleWaitingRepository(db),
 		RoomControl:   NewRoomControlRepository(db),
 		UserAccess:    NewUserAccessRepository(db),
+		ChatMessage:   NewChatMessageRepository(db),
+		PeopleBanned:  NewPeopleBannedRepository(db),
+		RoomRole:      NewRoomRoleRepository(db),
+		RoomInvite:    NewRoomInviteRepository(db),
+		RoomAllow:     NewRoomAllowRepository(db),
+		DirectMessage: NewDirectMessageRepository(db),
+		ChatReaction:  NewChatReactionRepository(db),
+		Participant:   NewRoomParticipantRepository(db),
+		Attempt:       NewPasscodeAttemptRepository(db),
 	}
 }
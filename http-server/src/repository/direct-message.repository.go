@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+
+	"gorm.io/gorm"
+)
+
+type DirectMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewDirectMessageRepository(db *gorm.DB) *DirectMessageRepository {
+	return &DirectMessageRepository{db: db}
+}
+
+func (r *DirectMessageRepository) FindOne(conds ...interface{}) (*model.DirectMessage, error) {
+	var message model.DirectMessage
+
+	err := r.db.First(&message, conds...).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (r *DirectMessageRepository) Save(data *model.DirectMessage) error {
+	return r.db.Save(&data).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"pry-teams/src/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasscodeAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewPasscodeAttemptRepository(db *gorm.DB) *PasscodeAttemptRepository {
+	return &PasscodeAttemptRepository{db: db}
+}
+
+// Failures returns the failures of the key within its window
+func (r *PasscodeAttemptRepository) Failures(key string) (int, error) {
+	var attempt model.PasscodeAttempt
+
+	err := r.db.First(&attempt, "key = ? AND expires_at > ?", key, time.Now()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+
+	return attempt.Failures, err
+}
+
+// Fail records a failure of the key, the window starts with the first
+// failure and a new one starts once it is over
+func (r *PasscodeAttemptRepository) Fail(key string, window time.Duration) error {
+	now := time.Now()
+	attempt := model.PasscodeAttempt{Key: key, Failures: 1, ExpiresAt: now.Add(window)}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Set{
+			{
+				Column: clause.Column{Name: "failures"},
+				Value: gorm.Expr(
+					"CASE WHEN passcode_attempt.expires_at > ? THEN passcode_attempt.failures + 1 ELSE 1 END", now,
+				),
+			},
+			{
+				Column: clause.Column{Name: "expires_at"},
+				Value: gorm.Expr(
+					"CASE WHEN passcode_attempt.expires_at > ? THEN passcode_attempt.expires_at ELSE ? END",
+					now, attempt.ExpiresAt,
+				),
+			},
+		},
+	}).Create(&attempt).Error
+}
+
+func (r *PasscodeAttemptRepository) Delete(conds ...interface{}) error {
+	var attempt model.PasscodeAttempt
+	return r.db.Delete(&attempt, conds...).Error
+}
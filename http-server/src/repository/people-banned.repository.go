@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PeopleBannedRepository struct {
+	db *gorm.DB
+}
+
+func NewPeopleBannedRepository(db *gorm.DB) *PeopleBannedRepository {
+	return &PeopleBannedRepository{db: db}
+}
+
+func (p *PeopleBannedRepository) Exists(roomId, userId string) (bool, error) {
+	var count int64
+	err := p.db.Model(&model.PeopleBanned{}).
+		Where("room_id = ? AND user_id = ?", roomId, userId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (p *PeopleBannedRepository) Save(data *model.PeopleBanned) error {
+	return p.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&data).Error
+}
+
+func (p *PeopleBannedRepository) Delete(conds ...interface{}) error {
+	var banned model.PeopleBanned
+	return p.db.Delete(&banned, conds...).Error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"fmt"
 	"pry-teams/src/model"
+	"pry-teams/src/types"
 
 	"gorm.io/gorm"
 )
@@ -38,6 +39,40 @@ func (p *PeopleRepository) Save(data *model.People) error {
 	return p.db.Save(&data).Error
 }
 
+// SaveLimited saves the people unless the room already has limit
+// participants, the devices of a user take a single seat, zero is unlimited
+func (p *PeopleRepository) SaveLimited(data *model.People, limit int) error {
+	if limit <= 0 {
+		return p.Save(data)
+	}
+
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		// the room control row serializes the concurrent joins of the room
+		err := tx.Exec(
+			"SELECT 1 FROM room_control WHERE room_id = ? FOR UPDATE", data.RoomID,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		// the other users in the room, a user already seated never adds one
+		var count int64
+		err = tx.Model(&model.People{}).
+			Where("room_id = ? AND user_id <> ?", data.RoomID, data.UserID).
+			Distinct("user_id").
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count >= int64(limit) {
+			return types.ErrRoomFull
+		}
+
+		return tx.Save(&data).Error
+	})
+}
+
 func (p *PeopleRepository) Delete(conds ...interface{}) error {
 	var people model.People
 	return p.db.Delete(&people, conds...).Error
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+	"pry-teams/src/types"
+
+	"gorm.io/gorm"
+)
+
+type RoomAllowRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomAllowRepository(db *gorm.DB) *RoomAllowRepository {
+	return &RoomAllowRepository{db: db}
+}
+
+func (r *RoomAllowRepository) FindMany(conds ...interface{}) ([]model.RoomAllow, error) {
+	var entries []model.RoomAllow
+	if err := r.db.Order("value").Find(&entries, conds...).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Replace sets the allowlist of the access type of the room to the values
+func (r *RoomAllowRepository) Replace(roomId string, access types.Access, values []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.RoomAllow{}, "room_id = ? AND access = ?", roomId, access).Error
+		if err != nil {
+			return err
+		}
+
+		if len(values) == 0 {
+			return nil
+		}
+
+		entries := make([]model.RoomAllow, 0, len(values))
+		for _, value := range values {
+			entries = append(entries, model.RoomAllow{RoomID: roomId, Access: access, Value: value})
+		}
+
+		return tx.Create(&entries).Error
+	})
+}
+
+// Exists reports whether one of the values is in the allowlist of the
+// access type of the room
+func (r *RoomAllowRepository) Exists(roomId string, access types.Access, values []string) (bool, error) {
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := r.db.Model(&model.RoomAllow{}).
+		Where("room_id = ? AND access = ? AND value IN ?", roomId, access, values).
+		Count(&count).Error
+
+	return count > 0, err
+}
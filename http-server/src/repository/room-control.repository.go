@@ -2,6 +2,7 @@ package repository
 
 import (
 	"pry-teams/src/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -33,3 +34,33 @@ func (r *RoomControlRepository) UpdateByRoomID(data *model.RoomControl) error {
 	return r.db.Model(model.RoomControl{}).
 		Where("room_id = ?", data.RoomID).Updates(&data).Error
 }
+
+// SetLocked locks or unlocks the room for new join requests
+func (r *RoomControlRepository) SetLocked(roomId string, locked bool) error {
+	return r.db.Model(model.RoomControl{}).
+		Where("room_id = ?", roomId).Update("locked", locked).Error
+}
+
+// SetPasscode stores the passcode hash, nil removes the passcode
+func (r *RoomControlRepository) SetPasscode(roomId string, hash *string) error {
+	return r.db.Model(model.RoomControl{}).
+		Where("room_id = ?", roomId).Update("passcode", hash).Error
+}
+
+// SetHostDeadline starts the countdown of the room without host, returns
+// false when a countdown is already running
+func (r *RoomControlRepository) SetHostDeadline(roomId string, deadline time.Time) (bool, error) {
+	result := r.db.Model(model.RoomControl{}).
+		Where("room_id = ? AND host_deadline IS NULL", roomId).Update("host_deadline", deadline)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClearHostDeadline stops the countdown matching the conditions, returns
+// false when there was no countdown to stop
+func (r *RoomControlRepository) ClearHostDeadline(query string, args ...interface{}) (bool, error) {
+	result := r.db.Model(model.RoomControl{}).
+		Where(query, args...).Update("host_deadline", nil)
+
+	return result.RowsAffected > 0, result.Error
+}
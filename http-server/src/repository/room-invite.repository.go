@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+
+	"gorm.io/gorm"
+)
+
+type RoomInviteRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomInviteRepository(db *gorm.DB) *RoomInviteRepository {
+	return &RoomInviteRepository{db: db}
+}
+
+func (r *RoomInviteRepository) FindOne(conds ...interface{}) (*model.RoomInvite, error) {
+	var invite model.RoomInvite
+
+	err := r.db.First(&invite, conds...).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (r *RoomInviteRepository) FindMany(conds ...interface{}) ([]model.RoomInvite, error) {
+	var invites []model.RoomInvite
+	if err := r.db.Order("created_at DESC").Find(&invites, conds...).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (r *RoomInviteRepository) Save(data *model.RoomInvite) error {
+	return r.db.Save(&data).Error
+}
+
+// Use counts a use of the invite, false when it was revoked, expired
+// or ran out of uses in the meantime
+func (r *RoomInviteRepository) Use(id string) (bool, error) {
+	result := r.db.Model(&model.RoomInvite{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Where("expires_at IS NULL OR expires_at > NOW()").
+		Where("max_uses IS NULL OR uses < max_uses").
+		Update("uses", gorm.Expr("uses + 1"))
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// Revoke disables the invite, false when there was no such invite
+func (r *RoomInviteRepository) Revoke(roomId, id string) (bool, error) {
+	result := r.db.Model(&model.RoomInvite{}).
+		Where("room_id = ? AND id = ? AND revoked_at IS NULL", roomId, id).
+		Update("revoked_at", gorm.Expr("NOW()"))
+
+	return result.RowsAffected == 1, result.Error
+}
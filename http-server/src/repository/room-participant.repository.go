@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoomParticipantRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomParticipantRepository(db *gorm.DB) *RoomParticipantRepository {
+	return &RoomParticipantRepository{db: db}
+}
+
+// Record remembers the user took part in the room, the last join
+// is updated when the user joins again
+func (r *RoomParticipantRepository) Record(roomId, userId string) error {
+	participant := model.RoomParticipant{RoomID: roomId, UserID: userId, LastJoinedAt: time.Now()}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_joined_at"}),
+	}).Create(&participant).Error
+}
+
+func (r *RoomParticipantRepository) Exists(roomId, userId string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ?", roomId, userId).
+		Count(&count).Error
+
+	return count > 0, err
+}
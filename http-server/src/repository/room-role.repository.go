@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"pry-teams/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoomRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomRoleRepository(db *gorm.DB) *RoomRoleRepository {
+	return &RoomRoleRepository{db: db}
+}
+
+func (r *RoomRoleRepository) FindOne(conds ...interface{}) (*model.RoomRole, error) {
+	var role model.RoomRole
+
+	err := r.db.First(&role, conds...).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoomRoleRepository) FindMany(conds ...interface{}) ([]model.RoomRole, error) {
+	var roles []model.RoomRole
+	if err := r.db.Find(&roles, conds...).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Save creates the role or replaces the role of the user in the room
+func (r *RoomRoleRepository) Save(data *model.RoomRole) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&data).Error
+}
+
+func (r *RoomRoleRepository) Delete(conds ...interface{}) error {
+	var role model.RoomRole
+	return r.db.Delete(&role, conds...).Error
+}
+
+func (r *RoomRoleRepository) Count(query interface{}, args ...interface{}) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.RoomRole{}).Where(query, args...).Count(&count).Error
+	return count, err
+}
@@ -27,7 +27,7 @@ func (r *RoomRepository) FindOne(conds ...interface{}) (*model.Room, error) {
 
 func (r *RoomRepository) FindMany(conds ...interface{}) ([]model.Room, error) {
 	var rooms []model.Room
-	if err := r.db.Find(&rooms, conds...).Error; err != nil {
+	if err := r.db.Order("created_at DESC").Find(&rooms, conds...).Error; err != nil {
 		return nil, err
 	}
 	return rooms, nil
@@ -37,6 +37,50 @@ func (r *RoomRepository) Save(data *model.Room) error {
 	return r.db.Save(&data).Error
 }
 
+func (r *RoomRepository) Delete(conds ...interface{}) error {
+	var room model.Room
+	return r.db.Delete(&room, conds...).Error
+}
+
+// DeleteAll deletes the room with every row belonging to it in one
+// transaction, older databases lack the cascading foreign keys
+func (r *RoomRepository) DeleteAll(room *model.Room) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"DELETE FROM chat_reaction WHERE message_id IN (SELECT id FROM chat_message WHERE room_id = ?)",
+			room.RoomId,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		dependents := []interface{}{
+			&model.People{},
+			&model.PeopleWaiting{},
+			&model.PeopleBanned{},
+			&model.ChatMessage{},
+			&model.DirectMessage{},
+			&model.RoomInvite{},
+			&model.RoomAllow{},
+			&model.RoomParticipant{},
+			&model.RoomRole{},
+			&model.RoomControl{},
+		}
+		for _, dependent := range dependents {
+			if err := tx.Where("room_id = ?", room.RoomId).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Where("key LIKE ?", "room:"+room.RoomId+"|%").Delete(&model.PasscodeAttempt{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Room{}, "id = ?", room.ID).Error
+	})
+}
+
 func (r *RoomRepository) FindOneWithPeople(conds ...interface{}) (*model.Room, error) {
 	var room model.Room
 
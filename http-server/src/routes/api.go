@@ -2,14 +2,45 @@ package routes
 
 import (
 	"pry-teams/src/controller"
+	"pry-teams/src/lib/broadcast"
 	s "pry-teams/src/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Api(r *gin.RouterGroup, service *s.ServiceContext) {
-	room := controller.NewRoomController(service.Room)
+func Api(r *gin.RouterGroup, service *s.ServiceContext, emitter *broadcast.Emitter) {
+	room := controller.NewRoomController(service.Room, emitter)
+	chat := controller.NewChatController(service.Chat)
+	calendar := controller.NewCalendarController(service.Calendar)
+	invite := controller.NewInviteController(service.Invite)
 
 	r.GET("/room/:id", room.GetRoom)
 	r.POST("/room/create", room.CreateRoom)
+	r.POST("/room", room.ScheduleRoom)
+	r.PUT("/room/:id", room.UpdateRoom)
+	r.DELETE("/room/:id", room.DeleteRoom)
+	r.GET("/rooms", room.GetRooms)
+	r.GET("/rooms/hosted", room.GetHostedRooms)
+	r.PATCH("/room/:id", room.UpdateDetails)
+	r.POST("/room/:id/host", room.AddHost)
+	r.DELETE("/room/:id/host/:userId", room.RemoveHost)
+	r.POST("/room/:id/guest", room.CreateGuest)
+	r.GET("/room/:id/control", room.GetControl)
+	r.PUT("/room/:id/control", room.UpdateControl)
+	r.GET("/room/:id/messages", chat.GetMessages)
+	r.GET("/room/:id/messages/search", chat.SearchMessages)
+	r.GET("/room/:id/invite.ics", calendar.Invite)
+	r.GET("/room/:id/invites", invite.GetInvites)
+	r.POST("/room/:id/invites", invite.CreateInvite)
+	r.DELETE("/room/:id/invites/:inviteId", invite.RevokeInvite)
+
+	r.GET("/calendar", calendar.GetToken)
+	r.POST("/calendar", calendar.RefreshToken)
+}
+
+// Public routes are registered before the auth middleware
+func Public(r *gin.RouterGroup, service *s.ServiceContext) {
+	calendar := controller.NewCalendarController(service.Calendar)
+
+	r.GET("/calendar/:token", calendar.Feed)
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	c "pry-teams/src/lib/common"
+	"pry-teams/src/lib/ical"
+	"pry-teams/src/model"
+	r "pry-teams/src/repository"
+	"pry-teams/src/types"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalendarService struct {
+	room       *r.RoomRepository
+	userAccess *r.UserAccessRepository
+	hosts      *RoomService
+}
+
+func NewCalendarService(repo *r.RepoContext, hosts *RoomService) *CalendarService {
+	return &CalendarService{
+		room:       repo.Room,
+		userAccess: repo.UserAccess,
+		hosts:      hosts,
+	}
+}
+
+// Invite returns the calendar invite of the room, the owner of the room
+// is the organizer whoever downloads the invite
+func (s *CalendarService) Invite(roomId string) (string, error) {
+	room, err := s.room.FindOne("room_id = ?", roomId)
+	if err != nil {
+		return "", err
+	}
+
+	event := roomEvent(room)
+
+	organizer, err := s.organizer(room.RoomId)
+	if err != nil {
+		return "", err
+	}
+	event.Organizer = organizer
+
+	calendar := ical.Calendar{Events: []ical.Event{event}}
+
+	return calendar.String(), nil
+}
+
+// organizer returns the profile of the room owner, nil when the owner
+// never left an email
+func (s *CalendarService) organizer(roomId string) (*ical.Organizer, error) {
+	ownerId, err := s.hosts.GetOwner(roomId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	access, err := s.userAccess.FindOne("user_id = ?", ownerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if access.Email == "" {
+		return nil, nil
+	}
+
+	return &ical.Organizer{Name: access.Name, Email: access.Email}, nil
+}
+
+// GetToken returns the calendar feed token of the user, a new token is
+// created when the user doesn't have one yet
+func (s *CalendarService) GetToken(userId string) (string, error) {
+	access, err := s.userAccess.FindOne("user_id = ?", userId)
+	if err == nil && access.CalendarToken != nil {
+		return *access.CalendarToken, nil
+	}
+
+	return s.RefreshToken(userId)
+}
+
+// RefreshToken replaces the calendar feed token, the previous feed url stops working
+func (s *CalendarService) RefreshToken(userId string) (string, error) {
+	token := c.Random(32)
+
+	access, err := s.userAccess.FindOne("user_id = ?", userId)
+	if err != nil {
+		access = &model.UserAccess{UserID: userId}
+	}
+
+	access.CalendarToken = &token
+	if err := s.userAccess.Save(access); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Feed returns the calendar of every room hosted by the token owner, archived
+// rooms are left out and ad-hoc rooms are listed from their creation
+func (s *CalendarService) Feed(token string) (string, error) {
+	access, err := s.userAccess.FindOne("calendar_token = ?", token)
+	if err != nil {
+		return "", err
+	}
+
+	rooms, err := s.room.FindMany(hostedBy+" AND archived_at IS NULL", access.UserID, types.Hosts)
+	if err != nil {
+		return "", err
+	}
+
+	calendar := ical.Calendar{Name: "Meetings"}
+	for _, room := range rooms {
+		calendar.Events = append(calendar.Events, roomEvent(&room))
+	}
+
+	return calendar.String(), nil
+}
+
+// JoinURL returns the meeting link of the room on the web app
+func JoinURL(roomId string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(c.Env("APP_URL"), "/"), roomId)
+}
+
+func roomEvent(room *model.Room) ical.Event {
+	url := JoinURL(room.RoomId)
+
+	event := ical.Event{
+		UID:      fmt.Sprintf("%s@pyr-teams", room.ID),
+		Summary:  fmt.Sprintf("Meeting %s", room.RoomId),
+		Location: url,
+		URL:      url,
+		Created:  room.CreatedAt,
+		Updated:  room.UpdatedAt,
+	}
+
+	if room.Title != nil && *room.Title != "" {
+		event.Summary = *room.Title
+	}
+
+	description := fmt.Sprintf("Join the meeting: %s\nMeeting code: %s", url, room.RoomId)
+	if room.Description != nil && *room.Description != "" {
+		description = *room.Description + "\n\n" + description
+	}
+	event.Description = description
+
+	if room.Scheduled() {
+		event.Start = *room.StartAt
+		event.End = *room.EndAt
+		if room.Recurrence != nil {
+			event.RRule = *room.Recurrence
+			event.Timezone = room.Location().String()
+		}
+	} else {
+		// ad-hoc rooms have no schedule, the invite starts when the room was created
+		event.Start = room.CreatedAt
+		event.End = room.CreatedAt.Add(time.Hour)
+	}
+
+	return event
+}
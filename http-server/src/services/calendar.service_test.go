@@ -0,0 +1,71 @@
+package services
+
+import (
+	"pry-teams/src/model"
+	"testing"
+	"time"
+)
+
+func TestRoomEvent(t *testing.T) {
+	t.Setenv("APP_URL", "https://meet.test/")
+	url := "https://meet.test/abc-defg-hij"
+
+	created := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	start := created.AddDate(0, 0, 7)
+	end := start.Add(30 * time.Minute)
+	timezone := "Europe/Berlin"
+	recurrence := "FREQ=WEEKLY;BYDAY=MO"
+
+	tests := []struct {
+		name     string
+		room     model.Room
+		start    time.Time
+		end      time.Time
+		rrule    string
+		timezone string
+	}{
+		{
+			// hosted rooms without a schedule are still listed in the feed
+			name:  "ad-hoc",
+			room:  model.Room{RoomId: "abc-defg-hij", CreatedAt: created},
+			start: created,
+			end:   created.Add(time.Hour),
+		},
+		{
+			name:  "scheduled",
+			room:  model.Room{RoomId: "abc-defg-hij", CreatedAt: created, StartAt: &start, EndAt: &end},
+			start: start,
+			end:   end,
+		},
+		{
+			name: "recurring",
+			room: model.Room{
+				RoomId: "abc-defg-hij", CreatedAt: created, StartAt: &start, EndAt: &end,
+				Timezone: &timezone, Recurrence: &recurrence,
+			},
+			start:    start,
+			end:      end,
+			rrule:    recurrence,
+			timezone: timezone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := roomEvent(&tt.room)
+
+			if !event.Start.Equal(tt.start) || !event.End.Equal(tt.end) {
+				t.Errorf("got %v - %v, want %v - %v", event.Start, event.End, tt.start, tt.end)
+			}
+			if event.RRule != tt.rrule {
+				t.Errorf("got rrule %q, want %q", event.RRule, tt.rrule)
+			}
+			if event.Timezone != tt.timezone {
+				t.Errorf("got timezone %q, want %q", event.Timezone, tt.timezone)
+			}
+			if event.URL != url {
+				t.Errorf("got url %q, want %q", event.URL, url)
+			}
+		})
+	}
+}
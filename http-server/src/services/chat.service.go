@@ -0,0 +1,479 @@
+package services
+
+import (
+	"errors"
+	"html"
+	c "pry-teams/src/lib/common"
+	"pry-teams/src/model"
+	r "pry-teams/src/repository"
+	"pry-teams/src/types"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// number of messages sent to a participant when joining the room
+const ChatBacklog = 50
+
+// maximum length of a chat or direct message
+const maxChatText = 4000
+
+// the search snippets mark the matches with control characters, replaced
+// by tags once the text is escaped
+const (
+	matchStart = "\x01"
+	matchStop  = "\x02"
+)
+
+// mainStream matches the messages of the room outside of the threads
+const mainStream = "room_id = ? AND parent_id IS NULL"
+
+type ChatService struct {
+	room        *r.RoomRepository
+	participant *r.RoomParticipantRepository
+	control     *r.RoomControlRepository
+	hosts       *RoomService
+	people      *r.PeopleRepository
+	message     *r.ChatMessageRepository
+	direct      *r.DirectMessageRepository
+	react       *r.ChatReactionRepository
+}
+
+func NewChatService(repo *r.RepoContext, hosts *RoomService) *ChatService {
+	return &ChatService{
+		room:        repo.Room,
+		participant: repo.Participant,
+		control:     repo.RoomControl,
+		hosts:       hosts,
+		people:      repo.People,
+		message:     repo.ChatMessage,
+		direct:      repo.DirectMessage,
+		react:       repo.ChatReaction,
+	}
+}
+
+// Post saves the message of the user posting from the socket, the sender,
+// the id and the timestamp are set by the server and never by the client.
+// Replies to a reply join the thread of the first message
+func (s *ChatService) Post(roomId, userId, socketId string, data *types.ChatMessage) (*model.ChatMessage, error) {
+	if err := checkText(data.Text); err != nil {
+		return nil, err
+	}
+
+	sender, err := s.sender(roomId, userId, socketId)
+	if err != nil {
+		return nil, err
+	}
+
+	message := model.ChatMessage{
+		ID:        cuid.New(),
+		RoomID:    roomId,
+		UserID:    sender.UserID,
+		Name:      sender.Name,
+		Text:      data.Text,
+		Timestamp: float64(time.Now().UnixMilli()),
+	}
+
+	if data.ParentID == nil || *data.ParentID == "" {
+		if err := s.message.Save(&message); err != nil {
+			return nil, err
+		}
+	} else {
+		parent, err := s.findMessage(roomId, *data.ParentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrThread
+		} else if err != nil {
+			return nil, err
+		}
+
+		message.ParentID = &parent.ID
+		if parent.ParentID != nil {
+			message.ParentID = parent.ParentID
+		}
+
+		if err := s.message.SaveReply(&message); err != nil {
+			return nil, err
+		}
+	}
+
+	data.ID = message.ID
+	data.ParentID = message.ParentID
+	data.UserID = message.UserID
+	data.Name = message.Name
+	data.Timestamp = message.Timestamp
+
+	return &message, nil
+}
+
+// Edit changes the text of a message of the user
+func (s *ChatService) Edit(roomId, userId, socketId, messageId, text string) (*types.ChatDelta, error) {
+	if err := checkText(text); err != nil {
+		return nil, err
+	}
+
+	sender, err := s.sender(roomId, userId, socketId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.findMessage(roomId, messageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.UserID != sender.UserID {
+		return nil, types.ErrNotAuthor
+	}
+
+	message.Text = text
+	message.EditedAt = c.Ptr(time.Now())
+	if err := s.message.Update(message, "text", "edited_at"); err != nil {
+		return nil, err
+	}
+
+	return &types.ChatDelta{
+		RoomID:    roomId,
+		MessageID: message.ID,
+		Text:      &message.Text,
+		EditedAt:  message.EditedAt,
+	}, nil
+}
+
+// Delete removes the text and the reactions of the message, authors delete
+// their own messages and hosts delete any message
+func (s *ChatService) Delete(roomId, userId, socketId, messageId string) (*types.ChatDelta, error) {
+	sender, err := s.sender(roomId, userId, socketId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.findMessage(roomId, messageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.UserID != sender.UserID {
+		host, err := s.hosts.IsHost(roomId, sender.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !host {
+			return nil, types.ErrForbidden
+		}
+	}
+
+	message.Text = ""
+	message.DeletedAt = c.Ptr(time.Now())
+	message.DeletedBy = &sender.UserID
+	if err := s.message.Update(message, "text", "deleted_at", "deleted_by"); err != nil {
+		return nil, err
+	}
+
+	if err := s.react.Delete("message_id = ?", message.ID); err != nil {
+		return nil, err
+	}
+
+	return &types.ChatDelta{
+		RoomID:    roomId,
+		MessageID: message.ID,
+		DeletedAt: message.DeletedAt,
+		DeletedBy: message.DeletedBy,
+	}, nil
+}
+
+// React adds or removes the reaction of the user to the message, the delta
+// carries the new total of the emoji
+func (s *ChatService) React(roomId, userId, socketId, messageId, emoji string) (*types.ChatDelta, error) {
+	if emoji == "" || len(emoji) > 32 {
+		return nil, types.ErrEmoji
+	}
+
+	sender, err := s.sender(roomId, userId, socketId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.findMessage(roomId, messageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.react.Toggle(message.ID, sender.UserID, emoji); err != nil {
+		return nil, err
+	}
+
+	reactions, err := s.react.Aggregate("message_id = ? AND emoji = ?", message.ID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := types.ReactionCount{Emoji: emoji, Users: []string{}}
+	if counts := reactions[message.ID]; len(counts) > 0 {
+		reaction = counts[0]
+	}
+
+	return &types.ChatDelta{
+		RoomID:    roomId,
+		MessageID: message.ID,
+		Reaction:  &reaction,
+	}, nil
+}
+
+// Thread returns the message starting the thread with a page of its replies,
+// page starts from 1 and counts backwards from the newest reply
+func (s *ChatService) Thread(roomId, userId, socketId, messageId string, page int) (*model.ChatMessage, error) {
+	if _, err := s.sender(roomId, userId, socketId); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.message.FindOne("id = ? AND room_id = ? AND parent_id IS NULL", messageId, roomId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrThread
+	} else if err != nil {
+		return nil, err
+	}
+
+	replies, err := s.message.FindLatest(ChatBacklog, (max(page, 1)-1)*ChatBacklog, "parent_id = ?", parent.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(replies)
+
+	// the parent goes first so a single lookup covers the whole thread
+	thread := append([]model.ChatMessage{*parent}, replies...)
+	if err := s.withReactions(thread); err != nil {
+		return nil, err
+	}
+
+	parent.Reactions = thread[0].Reactions
+	parent.Replies = thread[1:]
+
+	return parent, nil
+}
+
+// ThreadUpdate returns the reply count of the thread as a delta
+func (s *ChatService) ThreadUpdate(roomId, messageId string) (*types.ChatDelta, error) {
+	parent, err := s.message.FindOne("id = ? AND room_id = ?", messageId, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ChatDelta{
+		RoomID:      roomId,
+		MessageID:   parent.ID,
+		ReplyCount:  &parent.ReplyCount,
+		LastReplyAt: parent.LastReplyAt,
+	}, nil
+}
+
+// findMessage returns the message of the room, deleted messages can't change
+func (s *ChatService) findMessage(roomId, messageId string) (*model.ChatMessage, error) {
+	message, err := s.message.FindOne("id = ? AND room_id = ?", messageId, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.DeletedAt != nil {
+		return nil, types.ErrMessageDeleted
+	}
+
+	return message, nil
+}
+
+// withReactions sets the reaction totals of the messages
+func (s *ChatService) withReactions(messages []model.ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	reactions, err := s.react.Aggregate("message_id IN ?", ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i].Reactions = reactions[messages[i].ID]
+	}
+
+	return nil
+}
+
+// Direct saves a private message to the participant of the room, returns
+// the message and the recipient seat to deliver it to
+func (s *ChatService) Direct(roomId, userId, socketId, peerId, text string) (*model.DirectMessage, *model.People, error) {
+	if err := checkText(text); err != nil {
+		return nil, nil, err
+	}
+
+	sender, err := s.sender(roomId, userId, socketId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the recipient is looked up within the room only
+	recipient, err := s.people.FindOne("room_id = ? AND peer_id = ?", roomId, peerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, types.ErrRecipient
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.checkDirect(roomId, sender.UserID, recipient.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	message := model.DirectMessage{
+		ID:              cuid.New(),
+		RoomID:          roomId,
+		SenderID:        sender.UserID,
+		SenderPeerID:    sender.PeerID,
+		Name:            sender.Name,
+		RecipientID:     recipient.UserID,
+		RecipientPeerID: recipient.PeerID,
+		Text:            text,
+		Timestamp:       float64(time.Now().UnixMilli()),
+	}
+
+	if err := s.direct.Save(&message); err != nil {
+		return nil, nil, err
+	}
+
+	return &message, recipient, nil
+}
+
+// checkDirect validates the direct message against the policy of the room
+func (s *ChatService) checkDirect(roomId, senderId, recipientId string) error {
+	control, err := s.control.FindOne("room_id = ?", roomId)
+	if err != nil {
+		return err
+	}
+
+	switch control.DirectPolicy() {
+	case types.DirectEveryone:
+		return nil
+	case types.DirectHosts:
+		for _, userId := range []string{senderId, recipientId} {
+			host, err := s.hosts.IsHost(roomId, userId)
+			if err != nil {
+				return err
+			}
+			if host {
+				return nil
+			}
+		}
+	}
+
+	return types.ErrDirectDisabled
+}
+
+// sender returns the seat of the socket in the room, only people in
+// the room are able to chat
+func (s *ChatService) sender(roomId, userId, socketId string) (*model.People, error) {
+	people, err := s.people.FindOne(
+		"room_id = ? AND user_id = ? AND socket_id = ?", roomId, userId, socketId,
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrNotInRoom
+	}
+
+	return people, err
+}
+
+// GetRecent returns the latest messages of the room in chronological order
+func (s *ChatService) GetRecent(roomId string) ([]model.ChatMessage, error) {
+	messages, err := s.message.FindLatest(ChatBacklog, 0, mainStream, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(messages)
+
+	return messages, s.withReactions(messages)
+}
+
+// GetMessages returns a page of the room history, page starts from 1
+// and counts backwards from the newest message
+func (s *ChatService) GetMessages(roomId, userId string, page, limit int) ([]model.ChatMessage, int64, error) {
+	if err := s.checkHistory(roomId, userId); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.message.Count(mainStream, roomId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := s.message.FindLatest(limit, (page-1)*limit, mainStream, roomId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	slices.Reverse(messages)
+
+	return messages, total, s.withReactions(messages)
+}
+
+// Search returns a page of the messages of the room matching the query with
+// highlighted snippets, only the hosts and the past participants of the room
+// are able to search it
+func (s *ChatService) Search(roomId, userId, query string, page, limit int) ([]types.ChatSearchResult, int64, error) {
+	if err := s.checkHistory(roomId, userId); err != nil {
+		return nil, 0, err
+	}
+
+	results, total, err := s.message.Search(
+		roomId, query, matchStart, matchStop, limit, (page-1)*limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range results {
+		results[i].Snippet = strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>").
+			Replace(html.EscapeString(results[i].Snippet))
+	}
+
+	return results, total, nil
+}
+
+// checkHistory allows the hosts and the past participants of the room to
+// read its chat history
+func (s *ChatService) checkHistory(roomId, userId string) error {
+	if _, err := s.room.FindOne("room_id = ?", roomId); err != nil {
+		return err
+	}
+
+	participant, err := s.participant.Exists(roomId, userId)
+	if err != nil || participant {
+		return err
+	}
+
+	host, err := s.hosts.IsHost(roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if !host {
+		return types.ErrNotParticipant
+	}
+
+	return nil
+}
+
+// checkText rejects blank messages and messages over maxChatText characters
+func checkText(text string) error {
+	if strings.TrimSpace(text) == "" || utf8.RuneCountInString(text) > maxChatText {
+		return types.ErrChatText
+	}
+	return nil
+}
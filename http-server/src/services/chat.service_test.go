@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"pry-teams/src/types"
+	"strings"
+	"testing"
+)
+
+func TestCheckText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		err  error
+	}{
+		{name: "text", text: "hello"},
+		{name: "multi-byte at the limit", text: strings.Repeat("é", maxChatText)},
+		{name: "empty", text: "", err: types.ErrChatText},
+		{name: "blank", text: " \n\t ", err: types.ErrChatText},
+		{name: "too long", text: strings.Repeat("a", maxChatText+1), err: types.ErrChatText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkText(tt.text); !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -5,13 +5,21 @@ import (
 )
 
 type ServiceContext struct {
-	Room   *RoomService
-	People *PeopleService
+	Room     *RoomService
+	People   *PeopleService
+	Chat     *ChatService
+	Calendar *CalendarService
+	Invite   *InviteService
 }
 
 func NewContext(repo *r.RepoContext) *ServiceContext {
+	room := NewRoomService(repo)
+
 	return &ServiceContext{
-		Room:   NewRoomService(repo),
-		People: NewPeopleService(repo),
+		Room:     room,
+		People:   NewPeopleService(repo, room),
+		Chat:     NewChatService(repo, room),
+		Calendar: NewCalendarService(repo, room),
+		Invite:   NewInviteService(repo, room),
 	}
 }
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"net/url"
+	"pry-teams/src/lib/auth"
+	"pry-teams/src/model"
+	r "pry-teams/src/repository"
+	"pry-teams/src/types"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InviteService struct {
+	invite *r.RoomInviteRepository
+	hosts  *RoomService
+}
+
+func NewInviteService(repo *r.RepoContext, hosts *RoomService) *InviteService {
+	return &InviteService{
+		invite: repo.RoomInvite,
+		hosts:  hosts,
+	}
+}
+
+func (s *InviteService) checkHost(roomId, userId string) error {
+	host, err := s.hosts.IsHost(roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if !host {
+		return types.ErrForbidden
+	}
+
+	return nil
+}
+
+// Create adds an invite letting people skip the lobby of the room
+func (s *InviteService) Create(roomId, userId string, data *types.InviteRequest) (*model.RoomInvite, error) {
+	if err := s.checkHost(roomId, userId); err != nil {
+		return nil, err
+	}
+
+	if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return nil, types.ErrInviteExpiry
+	}
+
+	emails := make([]string, 0, len(data.Emails))
+	for _, email := range data.Emails {
+		emails = append(emails, strings.ToLower(strings.TrimSpace(email)))
+	}
+
+	invite := model.RoomInvite{
+		RoomID:    roomId,
+		CreatedBy: userId,
+		Emails:    emails,
+		MaxUses:   data.MaxUses,
+		ExpiresAt: data.ExpiresAt,
+	}
+
+	if err := s.invite.Save(&invite); err != nil {
+		return nil, err
+	}
+
+	if err := sign(&invite); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (s *InviteService) GetInvites(roomId, userId string) ([]model.RoomInvite, error) {
+	if err := s.checkHost(roomId, userId); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.invite.FindMany("room_id = ?", roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range invites {
+		if err := sign(&invites[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return invites, nil
+}
+
+func (s *InviteService) Revoke(roomId, userId, inviteId string) error {
+	if err := s.checkHost(roomId, userId); err != nil {
+		return err
+	}
+
+	ok, err := s.invite.Revoke(roomId, inviteId)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func sign(invite *model.RoomInvite) error {
+	token, err := auth.SignInvite(invite.ID)
+	if err != nil {
+		return err
+	}
+
+	invite.Token = token
+	invite.URL = JoinURL(invite.RoomID) + "?invite=" + url.QueryEscape(token)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestMain provides the .env file read by c.Env, the tests set
+// their values with t.Setenv which the file never overrides
+func TestMain(m *testing.M) {
+	created := false
+	if _, err := os.Stat(".env"); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(".env", nil, 0o600); err != nil {
+			panic(err)
+		}
+		created = true
+	}
+
+	code := m.Run()
+
+	if created {
+		os.Remove(".env")
+	}
+	os.Exit(code)
+}
@@ -1,19 +1,27 @@
 package services
 
 import (
+	"errors"
 	"pry-teams/src/model"
 	r "pry-teams/src/repository"
+	"pry-teams/src/types"
+
+	"gorm.io/gorm"
 )
 
 type PeopleService struct {
+	room          *RoomService
 	people        *r.PeopleRepository
 	PeopleWaiting *r.PeopleWaitingRepository
+	banned        *r.PeopleBannedRepository
 }
 
-func NewPeopleService(repo *r.RepoContext) *PeopleService {
+func NewPeopleService(repo *r.RepoContext, room *RoomService) *PeopleService {
 	return &PeopleService{
+		room:          room,
 		people:        repo.People,
 		PeopleWaiting: repo.PeopleWaiting,
+		banned:        repo.PeopleBanned,
 	}
 }
 
@@ -21,35 +29,173 @@ func (s *PeopleService) GetAllPeople() ([]model.People, error) {
 	return s.people.FindMany()
 }
 
-func (s *PeopleService) ToggleMuted(peerId string, state *string) error {
+// GetSeat returns the seat of the socket in the room
+func (s *PeopleService) GetSeat(roomId, socketId string) (*model.People, error) {
+	people, err := s.people.FindOne("room_id = ? AND socket_id = ?", roomId, socketId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrNotInRoom
+	}
+
+	return people, err
+}
+
+func (s *PeopleService) ToggleMuted(roomId, peerId string, state *string) error {
 	if state == nil {
-		return s.people.UpdateRaw("muted = NOT muted WHERE peer_id = ?", peerId)
+		return s.people.UpdateRaw("muted = NOT muted WHERE room_id = ? AND peer_id = ?", roomId, peerId)
 	}
-	return s.people.UpdateRaw("muted = ? WHERE peer_id = ?", state, peerId)
+	return s.people.UpdateRaw("muted = ? WHERE room_id = ? AND peer_id = ?", state, roomId, peerId)
 }
 
-func (s *PeopleService) ToggleVisible(peerId string, state *string) error {
+func (s *PeopleService) ToggleVisible(roomId, peerId string, state *string) error {
 	if state == nil {
-		return s.people.UpdateRaw("visible = NOT visible WHERE peer_id = ?", peerId)
+		return s.people.UpdateRaw("visible = NOT visible WHERE room_id = ? AND peer_id = ?", roomId, peerId)
 	}
-	return s.people.UpdateRaw("visible = ? WHERE peer_id = ?", state, peerId)
+	return s.people.UpdateRaw("visible = ? WHERE room_id = ? AND peer_id = ?", state, roomId, peerId)
 }
 
-func (s *PeopleService) Leave(roomId string, peerId string) (*int64, error) {
-	err := s.people.Delete("peer_id = ?", peerId)
+// Leave removes the seat of the socket from the room, returns the seat left
+func (s *PeopleService) Leave(roomId, socketId string) (*model.People, *int64, error) {
+	seat, err := s.GetSeat(roomId, socketId)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if err := s.people.Delete("id = ?", seat.ID); err != nil {
+		return nil, nil, err
+	}
+
+	count, err := s.people.Count("room_id = ?", roomId)
+	if err == nil {
+		s.endMeeting(roomId, count)
+	}
+
+	return seat, &count, err
+}
+
+// Remove evicts the people with all of their devices from the room and bans
+// the user until the meeting is over, the actor has to be able to manage
+// the participant role
+func (s *PeopleService) Remove(roomId, actorId, peerId string) ([]model.People, *int64, error) {
+	user, err := s.people.FindOne("room_id = ? AND peer_id = ?", roomId, peerId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.room.CanManage(roomId, actorId, user.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	devices, err := s.GetDevices(roomId, user.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.people.Delete("room_id = ? AND user_id = ?", roomId, user.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.banned.Save(&model.PeopleBanned{RoomID: roomId, UserID: user.UserID})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	count, err := s.people.Count("room_id = ?", roomId)
 
-	return &count, err
+	return devices, &count, err
+}
+
+// GetDevices returns every seat of the user in the room
+func (s *PeopleService) GetDevices(roomId, userId string) ([]model.People, error) {
+	return s.people.FindMany("room_id = ? AND user_id = ?", roomId, userId)
 }
 
-func (s *PeopleService) Disconnect(socketId string) (*model.People, *int64, error) {
+// Transfer makes the device the active one, the device active before
+// becomes a muted companion, from is nil when no device was active
+func (s *PeopleService) Transfer(roomId, userId, peerId string) (*model.People, *model.People, error) {
+	to, err := s.people.FindOne("room_id = ? AND user_id = ? AND peer_id = ?", roomId, userId, peerId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the active device may have left the room with its companions still in
+	from, err := s.people.FindOne(
+		"room_id = ? AND user_id = ? AND companion = ? AND peer_id <> ?",
+		roomId, userId, false, peerId,
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		from = nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if from != nil {
+		err = s.people.UpdateRaw("companion = ?, muted = ? WHERE id = ?", true, true, from.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		from.Companion, from.Muted = true, true
+	}
+
+	if err := s.people.UpdateRaw("companion = ? WHERE id = ?", false, to.ID); err != nil {
+		return nil, nil, err
+	}
+
+	to.Companion = false
+
+	return from, to, nil
+}
+
+// KeepSeat marks the seat of the disconnected socket as reconnecting
+// instead of leaving the room right away
+func (s *PeopleService) KeepSeat(socketId string) (*model.People, error) {
 	s.PeopleWaiting.Delete("socket_id = ?", socketId) // ignore error
 
 	user, err := s.people.FindOne("socket_id = ?", socketId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.people.UpdateRaw("reconnecting = ? WHERE id = ?", true, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Reconnecting = true
+	return user, nil
+}
+
+// Reconnect hands the seat kept for the user over to the new socket, the
+// seat keeps its state, returns the previous peer id or empty without seat,
+// a seat still held by a live socket is never taken over
+func (s *PeopleService) Reconnect(roomId string, user *model.People) (string, error) {
+	seat, err := s.people.FindOne(
+		"room_id = ? AND user_id = ? AND companion = ? AND reconnecting = ?",
+		roomId, user.UserID, user.Companion, true,
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	previous := seat.PeerID
+	seat.SocketID = user.SocketID
+	seat.PeerID = user.PeerID
+	seat.Instance = user.Instance
+	seat.Reconnecting = false
+
+	if err := s.people.Save(seat); err != nil {
+		return "", err
+	}
+
+	*user = *seat
+	return previous, nil
+}
+
+// DropSeat leaves the room when the seat kept for the socket wasn't taken over in time
+func (s *PeopleService) DropSeat(id, socketId string) (*model.People, *int64, error) {
+	user, err := s.people.FindOne("id = ? AND socket_id = ? AND reconnecting = ?", id, socketId, true)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -59,6 +205,17 @@ func (s *PeopleService) Disconnect(socketId string) (*model.People, *int64, erro
 	}
 
 	count, err := s.people.Count("room_id = ?", user.RoomID)
+	if err == nil {
+		s.endMeeting(user.RoomID, count)
+	}
 
 	return user, &count, err
 }
+
+// endMeeting clears the meeting state once the last people left
+func (s *PeopleService) endMeeting(roomId string, count int64) {
+	if count > 0 {
+		return
+	}
+	s.banned.Delete("room_id = ?", roomId) // ignore error
+}
@@ -3,21 +3,47 @@ package services
 import (
 	"errors"
 	"fmt"
-	"pry-teams/src/lib/array"
+	"pry-teams/src/lib/auth"
 	c "pry-teams/src/lib/common"
+	"pry-teams/src/lib/rrule"
 	"pry-teams/src/model"
 	r "pry-teams/src/repository"
 	"pry-teams/src/types"
+	"slices"
+	"strings"
+	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// earlyJoin returns how long before a scheduled meeting participants are able to join
+func earlyJoin() time.Duration {
+	return c.EnvDuration("MEETING_EARLY_JOIN", 15*time.Minute)
+}
+
+// wrong passcodes of a room lock the user out for the window, the address
+// is shared by everyone behind the same proxy or NAT so it is only throttled
+const (
+	passcodeUserLimit    = 5
+	passcodeAddressLimit = 50
+)
+
+// hostedBy matches the rooms where the user has one of the host roles
+const hostedBy = "room_id IN (SELECT room_id FROM room_role WHERE user_id = ? AND role IN ?)"
+
 type RoomService struct {
 	room          *r.RoomRepository
 	control       *r.RoomControlRepository
 	people        *r.PeopleRepository
 	peopleWaiting *r.PeopleWaitingRepository
 	userAccess    *r.UserAccessRepository
+	banned        *r.PeopleBannedRepository
+	role          *r.RoomRoleRepository
+	invite        *r.RoomInviteRepository
+	allow         *r.RoomAllowRepository
+	participant   *r.RoomParticipantRepository
+	attempt       *r.PasscodeAttemptRepository
 }
 
 func NewRoomService(repo *r.RepoContext) *RoomService {
@@ -27,6 +53,12 @@ func NewRoomService(repo *r.RepoContext) *RoomService {
 		people:        repo.People,
 		peopleWaiting: repo.PeopleWaiting,
 		userAccess:    repo.UserAccess,
+		banned:        repo.PeopleBanned,
+		role:          repo.RoomRole,
+		invite:        repo.RoomInvite,
+		allow:         repo.RoomAllow,
+		participant:   repo.Participant,
+		attempt:       repo.Attempt,
 	}
 }
 
@@ -55,25 +87,40 @@ func (s *RoomService) CreateRoomID() (string, error) {
 	return roomId, nil
 }
 
-func (s *RoomService) CreateRoom(userId string) (*model.Room, error) {
+// CreateRoom creates an ad-hoc room, or a scheduled meeting when schedule is given
+func (s *RoomService) CreateRoom(user *types.AuthUser, schedule *types.RoomSchedule) (*model.Room, error) {
 	roomId, err := s.CreateRoomID()
 	if err != nil {
 		return nil, err
 	}
 
 	// create a new room
-	room := model.Room{RoomId: roomId, Host: []string{userId}}
+	room := model.Room{RoomId: roomId}
+	if schedule != nil {
+		if err := setSchedule(&room, schedule); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.room.Save(&room); err != nil {
 		return nil, err
 	}
 
-	// getting user access or create new
-	access, err := s.userAccess.FindOne("user_id = ?", userId)
+	owner := model.RoomRole{RoomID: room.RoomId, UserID: user.ID, Role: types.Owner}
+	if err := s.role.Save(&owner); err != nil {
+		return nil, err
+	}
+
+	// getting user access or create new, the profile of the owner is kept
+	// up to date for the calendar invites
+	access, err := s.userAccess.FindOne("user_id = ?", user.ID)
 	if err != nil {
-		access = &model.UserAccess{UserID: userId}
-		if err := s.userAccess.Save(access); err != nil {
-			return nil, err
-		}
+		access = &model.UserAccess{UserID: user.ID}
+	}
+
+	access.Name, access.Email = user.Name, user.Email
+	if err := s.userAccess.Save(access); err != nil {
+		return nil, err
 	}
 
 	// create a room control
@@ -89,6 +136,244 @@ func (s *RoomService) CreateRoom(userId string) (*model.Room, error) {
 	return &room, nil
 }
 
+// GetHostedRooms returns the rooms hosted by the user, newest first
+func (s *RoomService) GetHostedRooms(userId string, archived bool) ([]model.Room, error) {
+	if archived {
+		return s.room.FindMany(hostedBy+" AND archived_at IS NOT NULL", userId, types.Hosts)
+	}
+	return s.room.FindMany(hostedBy+" AND archived_at IS NULL", userId, types.Hosts)
+}
+
+// findHosted returns the room when the user is one of the hosts
+func (s *RoomService) findHosted(roomId, userId string) (*model.Room, error) {
+	room, err := s.room.FindOne("room_id = ?", roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := s.IsHost(room.RoomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !host {
+		return nil, types.ErrForbidden
+	}
+
+	return room, nil
+}
+
+func (s *RoomService) UpdateRoom(roomId, userId string, schedule *types.RoomSchedule) (*model.Room, error) {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setSchedule(room, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.room.Save(room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (s *RoomService) DeleteRoom(roomId, userId string) error {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	return s.room.DeleteAll(room)
+}
+
+// EndRoom ends the meeting of a room hosted by the user, the people
+// are evicted and have to be told the meeting is over
+func (s *RoomService) EndRoom(roomId, userId string) error {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	return s.EndMeeting(room.RoomId)
+}
+
+// UpdateDetails renames, describes or archives the room, archived
+// rooms can't be joined until restored
+func (s *RoomService) UpdateDetails(roomId, userId string, data *types.RoomDetails) (*model.Room, error) {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Title != nil {
+		room.Title = data.Title
+	}
+
+	if data.Description != nil {
+		room.Description = data.Description
+	}
+
+	if data.Archived != nil {
+		if !*data.Archived {
+			room.ArchivedAt = nil
+		} else if room.ArchivedAt == nil {
+			room.ArchivedAt = c.Ptr(time.Now())
+		}
+	}
+
+	if err := s.room.Save(room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+// AddHost promotes the user to co-host
+func (s *RoomService) AddHost(roomId, userId, hostId string) ([]model.RoomRole, error) {
+	if err := s.SetRole(roomId, userId, hostId, types.CoHost); err != nil {
+		return nil, err
+	}
+	return s.GetRoles(roomId)
+}
+
+// RemoveHost demotes the co-host to attendee
+func (s *RoomService) RemoveHost(roomId, userId, hostId string) ([]model.RoomRole, error) {
+	if err := s.SetRole(roomId, userId, hostId, types.Attendee); err != nil {
+		return nil, err
+	}
+	return s.GetRoles(roomId)
+}
+
+// CreateGuestToken mints a token letting someone without an account
+// ask to join the room as a guest
+func (s *RoomService) CreateGuestToken(roomId, userId, name string) (*types.GuestToken, error) {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.ArchivedAt != nil {
+		return nil, types.ErrArchived
+	}
+
+	token, expiresAt, err := auth.SignGuest(room.RoomId, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.GuestToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *RoomService) GetControl(roomId, userId string) (*model.RoomControl, error) {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	control, err := s.control.FindOne("room_id = ?", room.RoomId)
+	if err != nil {
+		return nil, err
+	}
+
+	control.Allowlist, err = s.GetAllowlist(room.RoomId)
+
+	return control, err
+}
+
+// SetControl updates the room control for hosts outside of a live meeting
+func (s *RoomService) SetControl(roomId, userId string, state *types.Control) (*model.RoomControl, error) {
+	room, err := s.findHosted(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	control, err := s.UpdateControl(room.RoomId, userId, state)
+	if err != nil {
+		return nil, err
+	}
+
+	control.Allowlist, err = s.GetAllowlist(room.RoomId)
+
+	return control, err
+}
+
+// GetMeetings returns the scheduled meetings hosted by the user within [from, to),
+// recurring meetings are expanded into every occurrence
+func (s *RoomService) GetMeetings(userId string, from, to time.Time) ([]types.Meeting, error) {
+	rooms, err := s.room.FindMany(
+		hostedBy+" AND archived_at IS NULL AND start_at IS NOT NULL AND "+
+			"end_at IS NOT NULL AND (recurrence IS NOT NULL OR (start_at < ? AND end_at > ?))",
+		userId, types.Hosts, to, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	meetings := []types.Meeting{}
+	for _, room := range rooms {
+		occurrences, err := room.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		duration := room.EndAt.Sub(*room.StartAt)
+		for _, start := range occurrences {
+			meetings = append(meetings, types.Meeting{
+				RoomID:      room.RoomId,
+				Title:       room.Title,
+				Description: room.Description,
+				Timezone:    room.Timezone,
+				Recurrence:  room.Recurrence,
+				StartAt:     start,
+				EndAt:       start.Add(duration),
+			})
+		}
+	}
+
+	slices.SortFunc(meetings, func(a, b types.Meeting) int {
+		return a.StartAt.Compare(b.StartAt)
+	})
+
+	return meetings, nil
+}
+
+// setSchedule validates the schedule and applies it to the room
+func setSchedule(room *model.Room, schedule *types.RoomSchedule) error {
+	if !schedule.EndAt.After(schedule.StartAt) {
+		return fmt.Errorf("%w: end must be after start", types.ErrSchedule)
+	}
+
+	timezone := "UTC"
+	if schedule.Timezone != nil && *schedule.Timezone != "" {
+		timezone = *schedule.Timezone
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", types.ErrSchedule, timezone)
+	}
+
+	var recurrence *string
+	if schedule.Recurrence != nil && *schedule.Recurrence != "" {
+		rule, err := rrule.Parse(*schedule.Recurrence)
+		if err != nil {
+			return fmt.Errorf("%w: %v", types.ErrSchedule, err)
+		}
+		recurrence = c.Ptr(rule.String())
+	}
+
+	room.Title = &schedule.Title
+	room.Description = schedule.Description
+	room.StartAt = c.Ptr(schedule.StartAt.UTC())
+	room.EndAt = c.Ptr(schedule.EndAt.UTC())
+	room.Timezone = &timezone
+	room.Recurrence = recurrence
+
+	return nil
+}
+
 func (s *RoomService) GetRoomByID(roomId string) (*model.Room, error) {
 	room, err := s.room.FindOne("room_id = ?", roomId)
 	if err != nil {
@@ -105,50 +390,506 @@ func (s *RoomService) GetRoomByID(roomId string) (*model.Room, error) {
 	return room, err
 }
 
+func (s *RoomService) IsHost(roomId, userId string) (bool, error) {
+	role, err := s.GetRole(roomId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return role.IsHost(), nil
+}
+
+// GetRole returns the role of the user in the room, attendee by default
+func (s *RoomService) GetRole(roomId, userId string) (types.Role, error) {
+	role, err := s.role.FindOne("room_id = ? AND user_id = ?", roomId, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return types.Attendee, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return role.Role, nil
+}
+
+// GetOwner returns the id of the user owning the room
+func (s *RoomService) GetOwner(roomId string) (string, error) {
+	role, err := s.role.FindOne("room_id = ? AND role = ?", roomId, types.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	return role.UserID, nil
+}
+
+func (s *RoomService) GetRoles(roomId string) ([]model.RoomRole, error) {
+	return s.role.FindMany("room_id = ?", roomId)
+}
+
+// SetRole changes the role of the user in the room, hosts manage the other
+// roles while co-hosts are managed by the owner, the owner role is fixed
+func (s *RoomService) SetRole(roomId, actorId, userId string, role types.Role) error {
+	if !role.Valid() || role == types.Owner {
+		return types.ErrRole
+	}
+
+	if role.IsHost() && types.IsGuestID(userId) {
+		return types.ErrRole
+	}
+
+	actor, err := s.CanManage(roomId, actorId, userId)
+	if err != nil {
+		return err
+	}
+
+	if role == types.CoHost && actor != types.Owner {
+		return types.ErrForbidden
+	}
+
+	return s.role.Save(&model.RoomRole{RoomID: roomId, UserID: userId, Role: role})
+}
+
+// CanManage checks the actor is able to manage the user and returns the role
+// of the actor, hosts manage the other roles while co-hosts are managed by
+// the owner, the owner is never managed
+func (s *RoomService) CanManage(roomId, actorId, userId string) (types.Role, error) {
+	actor, err := s.GetRole(roomId, actorId)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := s.GetRole(roomId, userId)
+	if err != nil {
+		return "", err
+	}
+
+	if !actor.IsHost() || current == types.Owner {
+		return "", types.ErrForbidden
+	}
+
+	if current == types.CoHost && actor != types.Owner {
+		return "", types.ErrForbidden
+	}
+
+	return actor, nil
+}
+
+// SetRoleByPeer changes the role of the participant in the room
+func (s *RoomService) SetRoleByPeer(roomId, actorId, peerId string, role types.Role) (*model.People, error) {
+	people, err := s.people.FindOne("room_id = ? AND peer_id = ?", roomId, peerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SetRole(roomId, actorId, people.UserID, role); err != nil {
+		return nil, err
+	}
+
+	return people, nil
+}
+
+// CheckPermission validates the action against the role and the room control
+func (s *RoomService) CheckPermission(roomId, userId string, permission types.Permission) error {
+	role, err := s.GetRole(roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if allow, decided := role.Allow(permission); decided {
+		if !allow {
+			return types.ErrPermission
+		}
+		return nil
+	}
+
+	control, err := s.control.FindOne("room_id = ?", roomId)
+	if err != nil {
+		return err
+	}
+
+	if !control.Allow(permission) {
+		return types.ErrPermission
+	}
+
+	return nil
+}
+
 func (s *RoomService) CountPeople(roomId string) (int64, error) {
 	return s.people.Count("room_id = ?", roomId)
 }
 
-func (s *RoomService) AskToJoin(roomId string, user *model.People) (bool, error) {
+func (s *RoomService) AskToJoin(roomId string, user *model.People, credentials *types.Credentials) (types.JoinState, error) {
 	room, err := s.GetRoomByID(roomId)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
+	// the peer id belongs to someone else, seats of the same user
+	// are taken over before asking to join
 	people, _ := s.people.FindOne("peer_id = ?", user.PeerID)
 	if people != nil {
-		return false, types.ErrAlreadyExists
+		return "", types.ErrAlreadyExists
+	}
+
+	host := false
+	if !user.Guest {
+		if host, err = s.IsHost(roomId, user.UserID); err != nil {
+			return "", err
+		}
+	}
+
+	// hosts can't be banned from their own room
+	if !host {
+		banned, err := s.banned.Exists(roomId, user.UserID)
+		if err != nil {
+			return "", err
+		}
+
+		if banned {
+			return "", types.ErrBanned
+		}
+	}
+
+	if room.ArchivedAt != nil {
+		return "", types.ErrArchived
+	}
+
+	// a locked room refuses every new request, lobby included
+	if !host && room.RoomControl.Locked != nil && *room.RoomControl.Locked {
+		return "", types.ErrRoomLocked
+	}
+
+	// hosts are never turned away from their own room
+	limit := room.RoomControl.Limit()
+	if host {
+		limit = 0
 	}
 
-	var accepted bool
+	// the companion device of someone already in the room was admitted
+	if user.Companion {
+		count, err := s.people.Count(
+			"room_id = ? AND user_id = ? AND companion = ?", roomId, user.UserID, false,
+		)
+		if err != nil {
+			return "", err
+		}
+
+		if count > 0 {
+			if err = s.people.SaveLimited(user, limit); err != nil {
+				return "", err
+			}
+			return types.JoinAccepted, nil
+		}
+	}
 
-	// add condition require host here...
+	var invite *model.RoomInvite
+	if !host {
+		if invite, err = s.findInvite(roomId, user, credentials); err != nil {
+			return "", err
+		}
+	}
 
-	if array.Include(room.Host, user.UserID) ||
-		*room.RoomControl.AccessType == types.Open {
-		if err = s.people.Save(user); err != nil {
-			return false, err
+	// protected rooms ask everyone but the hosts and invited for the passcode
+	if !host && invite == nil && room.RoomControl.Passcode != nil {
+		if credentials.Passcode == "" {
+			return "", types.ErrPasscodeRequired
 		}
-		accepted = true
-	} else {
-		err := s.peopleWaiting.Save((*model.PeopleWaiting)(user))
+
+		err := bcrypt.CompareHashAndPassword(
+			[]byte(*room.RoomControl.Passcode), []byte(credentials.Passcode),
+		)
 		if err != nil {
-			return false, err
+			return "", types.ErrPasscode
+		}
+	}
+
+	admit := host || invite != nil
+	if !admit {
+		if admit, err = s.allowed(room, user, credentials); err != nil {
+			return "", err
+		}
+	}
+
+	// invite only rooms turn away everyone missing from the allowlist,
+	// guests were invited by a host and still go through the lobby
+	if !admit && !user.Guest && *room.RoomControl.AccessType == types.InviteOnly {
+		return "", types.ErrNotInvited
+	}
+	user.Preapproved = admit
+
+	// scheduled meetings only accept participants while running
+	if !host && !room.Joinable(time.Now(), earlyJoin()) {
+		return "", types.ErrNotStarted
+	}
+
+	// participants wait in the lobby until one of the hosts is present
+	if !host && *room.RoomControl.RequireHost {
+		present, err := s.HostPresent(roomId)
+		if err != nil {
+			return "", err
+		}
+
+		if !present {
+			// the invite is spent when queued, the waiter is admitted
+			// later without presenting it again
+			if err := s.useInvite(invite); err != nil {
+				return "", err
+			}
+
+			err := s.peopleWaiting.Save((*model.PeopleWaiting)(user))
+			if err != nil {
+				return "", err
+			}
+			return types.JoinWaitingHost, nil
+		}
+	}
+
+	// a valid invite skips the lobby, guests always go through it
+	if admit {
+		if err = s.people.SaveLimited(user, limit); err != nil {
+			return "", err
+		}
+
+		if err := s.useInvite(invite); err != nil {
+			if err := s.people.Delete("id = ?", user.ID); err != nil {
+				return "", err
+			}
+			return "", err
+		}
+
+		if err := s.participant.Record(roomId, user.UserID); err != nil {
+			return "", err
+		}
+
+		return types.JoinAccepted, nil
+	}
+
+	if err := s.peopleWaiting.Save((*model.PeopleWaiting)(user)); err != nil {
+		return "", err
+	}
+
+	return types.JoinWaiting, nil
+}
+
+// useInvite counts a use of the invite, nil when no invite was given
+func (s *RoomService) useInvite(invite *model.RoomInvite) error {
+	if invite == nil {
+		return nil
+	}
+
+	used, err := s.invite.Use(invite.ID)
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return types.ErrInvite
+	}
+
+	return nil
+}
+
+// findInvite returns the invite of the credentials, nil when no invite was
+// given, invites don't apply to guests
+func (s *RoomService) findInvite(roomId string, user *model.People, credentials *types.Credentials) (*model.RoomInvite, error) {
+	if credentials.Invite == "" || user.Guest {
+		return nil, nil
+	}
+
+	id, err := auth.ParseInvite(credentials.Invite)
+	if err != nil {
+		return nil, types.ErrInvite
+	}
+
+	invite, err := s.invite.FindOne("id = ? AND room_id = ?", id, roomId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrInvite
+	} else if err != nil {
+		return nil, err
+	}
+
+	// anyone is able to register an unverified address, an
+	// invite bound to emails only trusts a verified one
+	email := credentials.Email
+	if !credentials.EmailVerified {
+		email = ""
+	}
+
+	if !invite.Valid(time.Now(), email) {
+		return nil, types.ErrInvite
+	}
+
+	return invite, nil
+}
+
+// allowed reports whether the access type of the room lets the user in
+// without asking the host, guests always ask
+func (s *RoomService) allowed(room *model.Room, user *model.People, credentials *types.Credentials) (bool, error) {
+	if user.Guest {
+		return false, nil
+	}
+
+	access := *room.RoomControl.AccessType
+	switch access {
+	case types.Open:
+		return true, nil
+	case types.InviteOnly:
+		// anyone is able to register an unverified address
+		if !credentials.EmailVerified || credentials.Email == "" {
+			return false, nil
+		}
+		return s.allow.Exists(room.RoomId, access, []string{normalize(credentials.Email)})
+	case types.Domain:
+		// the domain of an unverified email can't be trusted
+		at := strings.LastIndex(credentials.Email, "@")
+		if !credentials.EmailVerified || at < 0 {
+			return false, nil
+		}
+		return s.allow.Exists(room.RoomId, access, []string{normalize(credentials.Email[at+1:])})
+	case types.Organization:
+		organizations := make([]string, 0, len(credentials.Organizations))
+		for _, organization := range credentials.Organizations {
+			organizations = append(organizations, normalize(organization))
+		}
+		return s.allow.Exists(room.RoomId, access, organizations)
+	}
+
+	return false, nil
+}
+
+// HostPresent reports whether one of the hosts is in the room
+func (s *RoomService) HostPresent(roomId string) (bool, error) {
+	count, err := s.people.Count(
+		"room_id = ? AND user_id IN (SELECT user_id FROM room_role WHERE room_id = ? AND role IN ?)",
+		roomId, roomId, types.Hosts,
+	)
+
+	return count > 0, err
+}
+
+// WaitingForHost reports whether the room requires a host and none is present
+func (s *RoomService) WaitingForHost(roomId string) (bool, error) {
+	room, err := s.GetRoomByID(roomId)
+	if err != nil {
+		return false, err
+	}
+
+	if !*room.RoomControl.RequireHost {
+		return false, nil
+	}
+
+	present, err := s.HostPresent(roomId)
+
+	return !present, err
+}
+
+// AdmitWaiting moves the people waiting for a host who would have been
+// admitted without asking once the host joined, returns the admitted people
+func (s *RoomService) AdmitWaiting(roomId, userId string) ([]model.People, error) {
+	room, err := s.GetRoomByID(roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := s.IsHost(roomId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !host {
+		return nil, nil
+	}
+
+	waiting, err := s.peopleWaiting.FindMany("room_id = ? AND preapproved = ?", room.RoomId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var admitted []model.People
+	for _, w := range waiting {
+		people, err := s.JoinAccepted(room.RoomId, w.PeerID)
+		if errors.Is(err, types.ErrRoomFull) {
+			// the others stay in the lobby until someone leaves
+			return admitted, nil
+		} else if err != nil {
+			return admitted, err
 		}
-		accepted = false
+		admitted = append(admitted, *people)
 	}
 
-	return accepted, nil
+	return admitted, nil
 }
 
-func (s *RoomService) JoinAccepted(peerID string) (*model.People, error) {
-	waiting, err := s.peopleWaiting.FindOne("peer_id = ?", peerID)
+// StartHostGrace starts the countdown of the room without host, the deadline
+// is shared between instances, returns false with the running deadline when
+// another socket or instance already started it
+func (s *RoomService) StartHostGrace(roomId string, d time.Duration) (time.Time, bool, error) {
+	deadline := time.Now().Add(d)
+
+	started, err := s.control.SetHostDeadline(roomId, deadline)
+	if err != nil || started {
+		return deadline, started, err
+	}
+
+	control, err := s.control.FindOne("room_id = ?", roomId)
+	if err != nil {
+		return deadline, false, err
+	}
+
+	if control.HostDeadline == nil {
+		// stopped in the meantime
+		return s.StartHostGrace(roomId, d)
+	}
+
+	return *control.HostDeadline, false, nil
+}
+
+// StopHostGrace stops the countdown of the room, returns false when none was running
+func (s *RoomService) StopHostGrace(roomId string) (bool, error) {
+	return s.control.ClearHostDeadline("room_id = ? AND host_deadline IS NOT NULL", roomId)
+}
+
+// ExpireHostGrace stops the countdown of the room once the deadline passed,
+// only one caller sees true which keeps instances from ending the meeting twice
+func (s *RoomService) ExpireHostGrace(roomId string) (bool, error) {
+	return s.control.ClearHostDeadline("room_id = ? AND host_deadline <= ?", roomId, time.Now())
+}
+
+// EndMeeting removes everyone from the room
+func (s *RoomService) EndMeeting(roomId string) error {
+	if _, err := s.StopHostGrace(roomId); err != nil {
+		return err
+	}
+
+	if err := s.peopleWaiting.Delete("room_id = ?", roomId); err != nil {
+		return err
+	}
+
+	if err := s.people.Delete("room_id = ?", roomId); err != nil {
+		return err
+	}
+
+	return s.banned.Delete("room_id = ?", roomId)
+}
+
+// JoinAccepted moves the request waiting in the lobby of the room into the
+// room, requests of other rooms are never found
+func (s *RoomService) JoinAccepted(roomId, peerID string) (*model.People, error) {
+	waiting, err := s.peopleWaiting.FindOne("room_id = ? AND peer_id = ?", roomId, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	control, err := s.control.FindOne("room_id = ?", waiting.RoomID)
 	if err != nil {
 		return nil, err
 	}
 
 	people := (*model.People)(waiting)
-	if err := s.people.Save(people); err != nil {
+	if err := s.people.SaveLimited(people, control.Limit()); err != nil {
+		return nil, err
+	}
+
+	if err := s.participant.Record(people.RoomID, people.UserID); err != nil {
 		return nil, err
 	}
 
@@ -159,8 +900,8 @@ func (s *RoomService) JoinAccepted(peerID string) (*model.People, error) {
 	return people, nil
 }
 
-func (s *RoomService) JoinRejected(peerID string) (*model.PeopleWaiting, error) {
-	waiting, err := s.peopleWaiting.FindOne("peer_id = ?", peerID)
+func (s *RoomService) JoinRejected(roomId, peerID string) (*model.PeopleWaiting, error) {
+	waiting, err := s.peopleWaiting.FindOne("room_id = ? AND peer_id = ?", roomId, peerID)
 	if err != nil {
 		return nil, err
 	}
@@ -172,33 +913,57 @@ func (s *RoomService) JoinRejected(peerID string) (*model.PeopleWaiting, error)
 	return waiting, nil
 }
 
-func (s *RoomService) JoinRoom(roomId string, peerId string) (*model.Room, *int64, error) {
+// JoinRoom enters the room with the seat of the socket, a host admits the
+// people waiting for a host and gets the lobby, returns the admitted people
+func (s *RoomService) JoinRoom(roomId, socketId string) (*model.Room, []model.People, *int64, error) {
 	room, err := s.GetRoomByID(roomId)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	people, err := s.people.FindOne("room_id = ? AND socket_id = ?", room.RoomId, socketId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil, types.ErrNotInRoom
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+
+	admitted, err := s.AdmitWaiting(room.RoomId, people.UserID)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	people, err := s.people.FindOne("peer_id = ?", peerId)
+	host, err := s.IsHost(room.RoomId, people.UserID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	if array.Include(room.Host, people.UserID) {
-		room.PeopleWaiting, err = s.peopleWaiting.FindMany("room_id = ?", roomId)
+	if host {
+		room.PeopleWaiting, err = s.peopleWaiting.FindMany("room_id = ?", room.RoomId)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	count, err := s.CountPeople(room.RoomId)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return room, &count, nil
+	return room, admitted, &count, nil
 }
 
-func (s *RoomService) UpdateControl(roomId, userId string, state *types.Control) error {
+// UpdateControl saves the room control, the passcode, the allowlist and the
+// participant limit are kept when nil, an empty passcode removes it
+func (s *RoomService) UpdateControl(roomId, userId string, state *types.Control) (*model.RoomControl, error) {
+	if err := s.setPasscode(roomId, state.Passcode); err != nil {
+		return nil, err
+	}
+
+	if err := s.setAllowlist(roomId, state.Allowlist); err != nil {
+		return nil, err
+	}
+
 	access := model.UserAccess{
 		UserID:      userId,
 		RequireHost: &state.RequireHost,
@@ -206,7 +971,7 @@ func (s *RoomService) UpdateControl(roomId, userId string, state *types.Control)
 	}
 
 	if err := s.userAccess.UpdateByUserID(&access); err != nil {
-		return err
+		return nil, err
 	}
 
 	control := model.RoomControl{
@@ -219,9 +984,147 @@ func (s *RoomService) UpdateControl(roomId, userId string, state *types.Control)
 		AllowVideo:       &state.AllowVideo,
 		RequireHost:      &state.RequireHost,
 		AccessType:       &state.AccessType,
+		MaxParticipants:  state.MaxParticipants,
+	}
+
+	// the participant limit and the direct message policy are kept when not given
+	if state.DirectMessages != "" {
+		control.DirectMessages = &state.DirectMessages
+	}
+
+	if err := s.control.UpdateByRoomID(&control); err != nil {
+		return nil, err
 	}
 
-	return s.control.UpdateByRoomID(&control)
+	return s.control.FindOne("room_id = ?", roomId)
+}
+
+// passcodeKeys returns the attempt keys of the user and the address in the room
+func passcodeKeys(roomId, userId, address string) (string, string) {
+	room := "room:" + roomId + "|"
+	return room + "user:" + userId, room + "ip:" + address
+}
+
+// PasscodeBlocked reports whether the user, or the address the user
+// connects from, tried too many wrong passcodes of the room
+func (s *RoomService) PasscodeBlocked(roomId, userId, address string) (bool, error) {
+	user, ip := passcodeKeys(roomId, userId, address)
+
+	failures, err := s.attempt.Failures(user)
+	if err != nil || failures >= passcodeUserLimit {
+		return failures >= passcodeUserLimit, err
+	}
+
+	failures, err = s.attempt.Failures(ip)
+
+	return failures >= passcodeAddressLimit, err
+}
+
+// PasscodeFailed counts a wrong passcode for the user and the address
+func (s *RoomService) PasscodeFailed(roomId, userId, address string) error {
+	s.attempt.Delete("expires_at <= ?", time.Now()) // ignore error
+
+	user, ip := passcodeKeys(roomId, userId, address)
+	window := c.EnvDuration("PASSCODE_LOCKOUT", 15*time.Minute)
+	for _, key := range []string{user, ip} {
+		if err := s.attempt.Fail(key, window); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PasscodeAccepted forgets the wrong passcodes of the user, the
+// address keeps its count as it may be shared with someone else
+func (s *RoomService) PasscodeAccepted(roomId, userId string) error {
+	user, _ := passcodeKeys(roomId, userId, "")
+	return s.attempt.Delete("key = ?", user)
+}
+
+func (s *RoomService) setPasscode(roomId string, passcode *string) error {
+	if passcode == nil {
+		return nil
+	}
+
+	if *passcode == "" {
+		return s.control.SetPasscode(roomId, nil)
+	}
+
+	if len(*passcode) < 4 || len(*passcode) > 32 {
+		return types.ErrPasscodeFormat
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(*passcode), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return s.control.SetPasscode(roomId, c.Ptr(string(hash)))
+}
+
+// LockRoom locks or unlocks the room, people inside stay when locked
+func (s *RoomService) LockRoom(roomId string, locked bool) (*model.RoomControl, error) {
+	if err := s.control.SetLocked(roomId, locked); err != nil {
+		return nil, err
+	}
+
+	return s.control.FindOne("room_id = ?", roomId)
+}
+
+// GetAllowlist returns the allowlists of every access type of the room
+func (s *RoomService) GetAllowlist(roomId string) (*types.Allowlist, error) {
+	entries, err := s.allow.FindMany("room_id = ?", roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlist := types.Allowlist{Emails: []string{}, Domains: []string{}, Organizations: []string{}}
+	for _, entry := range entries {
+		switch entry.Access {
+		case types.InviteOnly:
+			allowlist.Emails = append(allowlist.Emails, entry.Value)
+		case types.Domain:
+			allowlist.Domains = append(allowlist.Domains, entry.Value)
+		case types.Organization:
+			allowlist.Organizations = append(allowlist.Organizations, entry.Value)
+		}
+	}
+
+	return &allowlist, nil
+}
+
+// setAllowlist replaces the allowlists given, a nil list is kept as is
+func (s *RoomService) setAllowlist(roomId string, allowlist *types.Allowlist) error {
+	if allowlist == nil {
+		return nil
+	}
+
+	for _, access := range []types.Access{types.InviteOnly, types.Domain, types.Organization} {
+		list := allowlist.Values(access)
+		if list == nil {
+			continue
+		}
+
+		values := make([]string, 0, len(list))
+		for _, value := range list {
+			value = normalize(value)
+			if value != "" && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+
+		if err := s.allow.Replace(roomId, access, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalize makes allowlist values comparable regardless of case
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
 }
 
 func (s *RoomService) LeaveRoom(roomId string, peerId string) (*int64, error) {
@@ -1,9 +1,74 @@
 package types
 
+import "time"
+
+// ChatMessage as posted by the client, the id and the sender fields
+// are always set by the server. Aggregate is a display hint of the client,
+// false when the message continues the previous one of the same sender.
+// Replies carry the id of the message starting the thread
 type ChatMessage struct {
+	ID        string  `json:"id"`
 	UserID    string  `json:"userId"`
 	Name      string  `json:"name"`
 	Text      string  `json:"text"`
 	Timestamp float64 `json:"timestamp"`
 	Aggregate *bool   `json:"aggregate,omitempty"`
+	ParentID  *string `json:"parentId,omitempty"`
+}
+
+type ChatThread struct {
+	RoomID    string `json:"roomId"`
+	MessageID string `json:"messageId"`
+	Page      int    `json:"page"`
+}
+
+type ChatEdit struct {
+	RoomID    string `json:"roomId"`
+	MessageID string `json:"messageId"`
+	Text      string `json:"text"`
+}
+
+type ChatDelete struct {
+	RoomID    string `json:"roomId"`
+	MessageID string `json:"messageId"`
+}
+
+type ChatReact struct {
+	RoomID    string `json:"roomId"`
+	MessageID string `json:"messageId"`
+	Emoji     string `json:"emoji"`
+}
+
+// ChatDelta is the change of a message broadcast to the room, only the
+// fields changed are set
+type ChatDelta struct {
+	RoomID      string         `json:"roomId"`
+	MessageID   string         `json:"messageId"`
+	Text        *string        `json:"text,omitempty"`
+	EditedAt    *time.Time     `json:"editedAt,omitempty"`
+	DeletedAt   *time.Time     `json:"deletedAt,omitempty"`
+	DeletedBy   *string        `json:"deletedBy,omitempty"`
+	Reaction    *ReactionCount `json:"reaction,omitempty"`
+	ReplyCount  *int           `json:"replyCount,omitempty"`
+	LastReplyAt *time.Time     `json:"lastReplyAt,omitempty"`
+}
+
+// ReactionCount is the number of users who reacted with the emoji
+type ReactionCount struct {
+	Emoji string   `json:"emoji"`
+	Count int64    `json:"count"`
+	Users []string `json:"users"`
+}
+
+// ChatSearchResult is a message matching the search, the snippet is
+// html escaped with the matches wrapped in <mark> tags
+type ChatSearchResult struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	ParentID  *string   `json:"parentId"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	Timestamp float64   `json:"timestamp"`
+	CreatedAt time.Time `json:"createdAt"`
 }
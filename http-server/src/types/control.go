@@ -2,18 +2,70 @@ package types
 
 type Access string
 
+// Open rooms admit everyone and trusted rooms ask the host, invite only
+// rooms refuse users missing from the email allowlist, domain and
+// organization rooms admit users matching the allowlist
 const (
-	Open    Access = "open"
-	Trusted Access = "trusted"
+	Open         Access = "open"
+	Trusted      Access = "trusted"
+	InviteOnly   Access = "invite_only"
+	Domain       Access = "domain"
+	Organization Access = "organization"
+)
+
+type DirectPolicy string
+
+// Direct messages are sent by everyone, only when one of the two
+// participants is a host, or not at all
+const (
+	DirectEveryone DirectPolicy = "everyone"
+	DirectHosts    DirectPolicy = "hosts"
+	DirectOff      DirectPolicy = "off"
+)
+
+type Permission string
+
+const (
+	AllowShareScreen Permission = "share-screen"
+	AllowSendChat    Permission = "send-chat"
+	AllowReaction    Permission = "reaction"
+	AllowMicrophone  Permission = "microphone"
+	AllowVideo       Permission = "video"
 )
 
 type Control struct {
-	HostManagement   bool   `json:"hostManagement"`
-	AllowShareScreen bool   `json:"allowShareScreen"`
-	AllowSendChat    bool   `json:"allowSendChat"`
-	AllowReaction    bool   `json:"allowReaction"`
-	AllowMicrophone  bool   `json:"allowMicrophone"`
-	AllowVideo       bool   `json:"allowVideo"`
-	RequireHost      bool   `json:"requireHost"`
-	AccessType       Access `json:"access"`
+	HostManagement   bool         `json:"hostManagement"`
+	AllowShareScreen bool         `json:"allowShareScreen"`
+	AllowSendChat    bool         `json:"allowSendChat"`
+	AllowReaction    bool         `json:"allowReaction"`
+	AllowMicrophone  bool         `json:"allowMicrophone"`
+	AllowVideo       bool         `json:"allowVideo"`
+	RequireHost      bool         `json:"requireHost"`
+	AccessType       Access       `json:"access" binding:"required,oneof=open trusted invite_only domain organization"`
+	Passcode         *string      `json:"passcode,omitempty"`
+	Protected        bool         `json:"protected"`
+	Allowlist        *Allowlist   `json:"allowlist,omitempty"`
+	MaxParticipants  *int         `json:"maxParticipants" binding:"omitempty,min=0,max=10000"`
+	Locked           bool         `json:"locked"`
+	DirectMessages   DirectPolicy `json:"directMessages" binding:"omitempty,oneof=everyone hosts off"`
+}
+
+// Allowlist of the invite only, domain and organization access types
+type Allowlist struct {
+	Emails        []string `json:"emails" binding:"omitempty,dive,email"`
+	Domains       []string `json:"domains" binding:"omitempty,dive,fqdn"`
+	Organizations []string `json:"organizations" binding:"omitempty,dive,min=1,max=255"`
+}
+
+// Values returns the allowlist entries of the access type
+func (a *Allowlist) Values(access Access) []string {
+	switch access {
+	case InviteOnly:
+		return a.Emails
+	case Domain:
+		return a.Domains
+	case Organization:
+		return a.Organizations
+	}
+	return nil
 }
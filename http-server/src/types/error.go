@@ -6,4 +6,31 @@ var (
 	ErrAlreadyExists error = errors.New("people already exists on room")
 	ErrConnection    error = errors.New("connection error, please try again")
 	ErrUnauthorized  error = errors.New("unauthorized")
+	ErrPermission    error = errors.New("not allowed by the host")
+	ErrForbidden     error = errors.New("only host can perform this action")
+	ErrBanned        error = errors.New("you have been removed from this room")
+	ErrSchedule      error = errors.New("invalid meeting schedule")
+	ErrNotStarted    error = errors.New("meeting is not in progress")
+	ErrArchived      error = errors.New("meeting has been archived")
+	ErrRole          error = errors.New("invalid role")
+	ErrGuestRoom     error = errors.New("guest access is not valid for this room")
+
+	ErrPasscodeRequired error = errors.New("meeting passcode is required")
+	ErrPasscode         error = errors.New("invalid meeting passcode")
+	ErrPasscodeFormat   error = errors.New("passcode must be between 4 and 32 characters")
+	ErrTooManyAttempts  error = errors.New("too many attempts, please try again later")
+	ErrInvite           error = errors.New("invite link is invalid or has expired")
+	ErrInviteExpiry     error = errors.New("invite expiry must be in the future")
+	ErrNotInvited       error = errors.New("you are not invited to this meeting")
+	ErrRoomFull         error = errors.New("meeting is full")
+	ErrRoomLocked       error = errors.New("meeting has been locked by the host")
+	ErrNotInRoom        error = errors.New("you are not in this meeting")
+	ErrRecipient        error = errors.New("participant is not in this meeting")
+	ErrDirectDisabled   error = errors.New("direct messages are not allowed by the host")
+	ErrNotAuthor        error = errors.New("only the author can change this message")
+	ErrMessageDeleted   error = errors.New("message has been deleted")
+	ErrChatText         error = errors.New("message text must be between 1 and 4000 characters")
+	ErrEmoji            error = errors.New("invalid emoji")
+	ErrThread           error = errors.New("thread not found")
+	ErrNotParticipant   error = errors.New("only participants of this meeting can read its chat")
 )
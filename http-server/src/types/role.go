@@ -0,0 +1,46 @@
+package types
+
+type Role string
+
+const (
+	Owner     Role = "owner"
+	CoHost    Role = "co-host"
+	Presenter Role = "presenter"
+	Attendee  Role = "attendee"
+	Viewer    Role = "viewer"
+)
+
+// Hosts are the roles allowed to manage the room
+var Hosts = []Role{Owner, CoHost}
+
+func (r Role) Valid() bool {
+	switch r {
+	case Owner, CoHost, Presenter, Attendee, Viewer:
+		return true
+	}
+	return false
+}
+
+func (r Role) IsHost() bool {
+	return r == Owner || r == CoHost
+}
+
+// Allow reports whether the role grants or denies the permission regardless
+// of the room control, decided is false when the room control applies
+func (r Role) Allow(permission Permission) (allow bool, decided bool) {
+	if r.IsHost() {
+		return true, true
+	}
+
+	switch permission {
+	case AllowShareScreen, AllowMicrophone, AllowVideo:
+		if r == Presenter {
+			return true, true
+		}
+		if r == Viewer {
+			return false, true
+		}
+	}
+
+	return false, false
+}
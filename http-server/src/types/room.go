@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+type RoomSchedule struct {
+	Title       string    `json:"title" binding:"required,max=255"`
+	Description *string   `json:"description"`
+	StartAt     time.Time `json:"startAt" binding:"required"`
+	EndAt       time.Time `json:"endAt" binding:"required,gtfield=StartAt"`
+	Timezone    *string   `json:"timezone"`
+	Recurrence  *string   `json:"recurrence"`
+}
+
+type RoomDetails struct {
+	Title       *string `json:"title" binding:"omitempty,max=255"`
+	Description *string `json:"description"`
+	Archived    *bool   `json:"archived"`
+}
+
+type RoomHost struct {
+	UserID string `json:"userId" binding:"required"`
+}
+
+type GuestRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+type GuestToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type InviteRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	MaxUses   *int       `json:"maxUses" binding:"omitempty,min=1"`
+	Emails    []string   `json:"emails" binding:"omitempty,dive,email"`
+}
+
+type Meeting struct {
+	RoomID      string    `json:"roomId"`
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Timezone    *string   `json:"timezone,omitempty"`
+	Recurrence  *string   `json:"recurrence,omitempty"`
+	StartAt     time.Time `json:"startAt"`
+	EndAt       time.Time `json:"endAt"`
+}
@@ -3,8 +3,28 @@ package types
 type Join struct {
 	RoomID string `json:"roomId"`
 	User   User   `json:"user"`
+	Credentials
 }
 
+// Credentials are given to join a room without waiting in the lobby,
+// the identity fields are set by the server from the authenticated user
+type Credentials struct {
+	Passcode      string   `json:"passcode,omitempty"`
+	Invite        string   `json:"invite,omitempty"`
+	Email         string   `json:"-"`
+	EmailVerified bool     `json:"-"`
+	Organizations []string `json:"-"`
+}
+
+type JoinState string
+
+const (
+	JoinAccepted    JoinState = "accepted"
+	JoinWaiting     JoinState = "waiting"
+	JoinWaitingHost JoinState = "waiting-host"
+	JoinReconnected JoinState = "reconnected"
+)
+
 type Emit struct {
 	RoomID string `json:"roomId"`
 	PeerID string `json:"peerId,omitempty"`
@@ -15,6 +35,12 @@ type ChatEmit struct {
 	Message ChatMessage `json:"message,omitempty"`
 }
 
+type DirectEmit struct {
+	RoomID string `json:"roomId"`
+	PeerID string `json:"peerId"`
+	Text   string `json:"text"`
+}
+
 type ReactionEmit struct {
 	RoomID   string `json:"roomId"`
 	Reaction string `json:"reaction,omitempty"`
@@ -24,3 +50,61 @@ type ControlEmit struct {
 	RoomID  string  `json:"roomId"`
 	Control Control `json:"control,omitempty"`
 }
+
+type LockEmit struct {
+	RoomID string `json:"roomId"`
+	Locked bool   `json:"locked"`
+}
+
+type RoleEmit struct {
+	RoomID string `json:"roomId"`
+	PeerID string `json:"peerId"`
+	UserID string `json:"userId,omitempty"`
+	Role   Role   `json:"role"`
+}
+
+type ErrorEmit struct {
+	Event   string `json:"event"`
+	RoomID  string `json:"roomId,omitempty"`
+	Message string `json:"message"`
+}
+
+type Refresh struct {
+	Token string `json:"token"`
+}
+
+type SessionEmit struct {
+	Seconds   int64 `json:"seconds"`
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+type ReconnectEmit struct {
+	RoomID         string `json:"roomId"`
+	PeerID         string `json:"peerId"`
+	PreviousPeerID string `json:"previousPeerId"`
+	User           User   `json:"user"`
+}
+
+type TransferEmit struct {
+	RoomID string `json:"roomId"`
+	UserID string `json:"userId"`
+	// From is empty when no device was active
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Device struct {
+	PeerID       string `json:"peerId"`
+	Name         string `json:"name"`
+	Muted        bool   `json:"muted"`
+	Visible      bool   `json:"visible"`
+	Companion    bool   `json:"companion"`
+	Reconnecting bool   `json:"reconnecting"`
+	Current      bool   `json:"current"`
+}
+
+type CountdownEmit struct {
+	RoomID   string `json:"roomId"`
+	Seconds  int64  `json:"seconds"`
+	Deadline int64  `json:"deadline"`
+}
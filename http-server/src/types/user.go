@@ -1,23 +1,54 @@
 package types
 
 import (
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	supaTypes "github.com/supabase-community/gotrue-go/types"
 	"github.com/zishang520/socket.io/v2/socket"
 )
 
 type User struct {
-	UserID  string  `json:"userId"`
-	PeerID  string  `json:"peerId"`
-	Name    string  `json:"name"`
-	Photo   *string `json:"photo,omitempty"`
-	Muted   bool    `json:"muted"`
-	Visible bool    `json:"visible"`
-	Host    bool    `json:"host"`
+	UserID    string  `json:"userId"`
+	PeerID    string  `json:"peerId"`
+	Name      string  `json:"name"`
+	Photo     *string `json:"photo,omitempty"`
+	Muted     bool    `json:"muted"`
+	Visible   bool    `json:"visible"`
+	Host      bool    `json:"host"`
+	Role      Role    `json:"role,omitempty"`
+	Guest     bool    `json:"guest"`
+	Companion bool    `json:"companion"`
+}
+
+// guest identities are prefixed so they never collide with account ids
+const GuestPrefix = "guest:"
+
+func IsGuestID(id string) bool {
+	return strings.HasPrefix(id, GuestPrefix)
+}
+
+// AuthUser is the authenticated identity, the same whichever
+// provider issued the token
+type AuthUser struct {
+	ID    string `json:"id"`
+	Email string `json:"email,omitempty"`
+	// only verified emails are matched against the domain allowlist
+	EmailVerified bool           `json:"emailVerified,omitempty"`
+	Organizations []string       `json:"organizations,omitempty"`
+	Name          string         `json:"name,omitempty"`
+	Photo         string         `json:"photo,omitempty"`
+	Provider      string         `json:"provider"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	// when the token expires, zero when it never does
+	ExpiresAt time.Time `json:"-"`
+	// guests are only allowed in the room their token was minted for
+	Guest  bool   `json:"guest,omitempty"`
+	RoomID string `json:"roomId,omitempty"`
 }
 
 type UserResponse struct {
-	*supaTypes.UserResponse
+	*AuthUser
 }
 
 func (u *UserResponse) Get(ctx *gin.Context) error {
@@ -26,12 +57,12 @@ func (u *UserResponse) Get(ctx *gin.Context) error {
 		return ErrUnauthorized
 	}
 
-	user, ok := context.(*supaTypes.UserResponse)
+	user, ok := context.(*AuthUser)
 	if !ok {
 		return ErrUnauthorized
 	}
 
-	*u = UserResponse{UserResponse: user}
+	*u = UserResponse{AuthUser: user}
 
 	return nil
 }
@@ -42,12 +73,12 @@ func (u *UserResponse) GetFromSocket(s *socket.Socket) error {
 		return ErrUnauthorized
 	}
 
-	user, ok := userData["user"].(*supaTypes.UserResponse)
+	user, ok := userData["user"].(*AuthUser)
 	if !ok {
 		return ErrUnauthorized
 	}
 
-	*u = UserResponse{UserResponse: user}
+	*u = UserResponse{AuthUser: user}
 
 	return nil
 }
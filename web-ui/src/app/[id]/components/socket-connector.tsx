@@ -103,7 +103,7 @@ export function SocketConnector({
                 label: 'Admit',
                 onClick: () => {
                     values.forEach(v => {
-                        socket.emit('request:accept', v.peerId)
+                        socket.emit('request:accept', { roomId: room.roomId, peerId: v.peerId })
                     })
                 }
             }